@@ -16,30 +16,31 @@ NODES=${NODES:-'n0.local,n1.local,n2.local'}
 
 {
     echo No durability
-    gorgon_couchbase -gorgon-nodes $NODES -gorgon-concurrency 8 run
+    gorgon_couchbase -gorgon-nodes $NODES -gorgon-output-dir output -gorgon-concurrency 8 run || echo "Exit code $?"
 
     echo majorityPersistActive
     gorgon_couchbase \
         -gorgon-nodes $NODES \
+        -gorgon-output-dir output \
         -gorgon-match '*~*~*' \
         -gorgon-concurrency 10 \
         -durability majorityPersistActive \
         -replicas 2 \
-        run
+        run || echo "Exit code $?"
 
     echo majorityPersistActive client-over-rpc
     gorgon_couchbase \
         -gorgon-nodes $NODES \
+        -gorgon-output-dir output \
         -gorgon-match '*~*~*' \
         -gorgon-concurrency 18 \
         -durability majorityPersistActive \
         -replicas 2 \
         -client-over-rpc \
-        run
+        run || echo "Exit code $?"
 } 2>&1 | tee gorgon.log
 
-touch .html
-tar -czf files.tgz gorgon.log *.html
+tar -czf files.tgz gorgon.log output
 
 echo
 echo DONE
@@ -0,0 +1,145 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// Linearizable returns a Checker that uses porcupine to check each partition
+// of a history for linearizability against model. A visualization is written
+// for every partition that is not ok.
+func Linearizable(model gorgon.Model) gorgon.Checker {
+	return &linearizable{model}
+}
+
+type linearizable struct {
+	model gorgon.Model
+}
+
+func (*linearizable) Name() string {
+	return "Linearizable"
+}
+
+func (checker *linearizable) Check(ctx context.Context, history []gorgon.Operation,
+	opt *gorgon.CheckOptions) ([]gorgon.PartitionResult, error) {
+	model := checker.model
+	ndmodel := porcupine.NondeterministicModel{
+		Init: model.Init,
+		Step: func(state, input, output interface{}) []interface{} {
+			return model.Step(state, input.(gorgon.Instruction), output)
+		},
+		Equal: model.Equal,
+		DescribeOperation: func(input, output interface{}) string {
+			return model.DescribeOperation(input.(gorgon.Instruction), output)
+		},
+		DescribeState: model.DescribeState,
+	}
+	dmodel := ndmodel.ToModel()
+	partitions := [][]gorgon.Operation{history}
+	if model.Partition != nil {
+		partitions = model.Partition(history)
+	}
+	results := make([]gorgon.PartitionResult, len(partitions))
+	visErrs := make([]error, len(partitions))
+	forEachParallel(len(partitions), opt.Workers, func(i int) {
+		filePath := opt.ArtifactPath(fmt.Sprintf("%d.html", i))
+		results[i], visErrs[i] = checkPartition(ctx, dmodel, partitions[i], opt.Timeout, filePath)
+		results[i].Index = i
+	})
+	for _, err := range visErrs {
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func checkPartition(ctx context.Context, model porcupine.Model, part []gorgon.Operation,
+	timeout time.Duration, filePath string) (partResult gorgon.PartitionResult, err error) {
+	hist := make([]porcupine.Operation, len(part))
+	for i := 0; i < len(part); i++ {
+		op := part[i]
+		hist[i] = porcupine.Operation{
+			ClientId: op.ClientId,
+			Input:    op.Input,
+			Call:     op.Call,
+			Output:   op.Output,
+			Return:   op.Return,
+		}
+	}
+	result, info, cancelled := checkOperations(ctx, model, hist, timeout)
+	partResult = gorgon.PartitionResult{Operations: len(part), Result: checkResults[result]}
+	if !cancelled && result != porcupine.Ok {
+		if err = porcupine.VisualizePath(model, info, filePath); err == nil {
+			partResult.Visualization = filePath
+		}
+	}
+	return
+}
+
+var checkResults = map[porcupine.CheckResult]gorgon.CheckResult{
+	porcupine.Ok:      gorgon.CheckOk,
+	porcupine.Illegal: gorgon.CheckIllegal,
+	porcupine.Unknown: gorgon.CheckUnknown,
+}
+
+// checkOperations runs porcupine on history until it finishes, timeout passes
+// or ctx is done. The search can only be stopped through its own timeout, so
+// the timeout is bounded by the deadline of ctx; a search cancelled without a
+// deadline is abandoned and keeps running until timeout, if any.
+func checkOperations(ctx context.Context, model porcupine.Model, history []porcupine.Operation,
+	timeout time.Duration) (porcupine.CheckResult, porcupine.LinearizationInfo, bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return porcupine.Unknown, porcupine.LinearizationInfo{}, true
+		}
+		if timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+	type checked struct {
+		result porcupine.CheckResult
+		info   porcupine.LinearizationInfo
+	}
+	done := make(chan checked, 1)
+	go func() {
+		result, info := porcupine.CheckOperationsVerbose(model, history, timeout)
+		done <- checked{result, info}
+	}()
+	select {
+	case c := <-done:
+		return c.result, c.info, false
+	case <-ctx.Done():
+		return porcupine.Unknown, porcupine.LinearizationInfo{}, true
+	}
+}
+
+// forEachParallel calls fn for every index in [0, n) on at most workers
+// goroutines.
+func forEachParallel(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	indices := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
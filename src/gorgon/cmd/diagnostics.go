@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"net/rpc"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/jrpc"
+	"github.com/pavlosg/gorgon/src/gorgon/log"
+	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
+)
+
+const (
+	// diagnosticsTimeout bounds each diagnostic command run on a node.
+	diagnosticsTimeout = 10 * time.Minute
+	// collectTimeout bounds the collection of the diagnostics of a node, so
+	// that a hung node does not block the run.
+	collectTimeout = time.Hour
+	// fetchTimeout bounds the fetch of every chunk of the diagnostics.
+	fetchTimeout = time.Minute
+)
+
+// collectDiagnostics asks the DiagnosticsRpc of every node, in parallel, for
+// its logs and the output of its diagnostic commands, and saves them into dir.
+// Errors are logged, as diagnostics are best effort.
+func collectDiagnostics(opt *gorgon.Options, dir string) {
+	log.Info("Collecting diagnostics of %d nodes", len(opt.Nodes))
+	wg := &sync.WaitGroup{}
+	for _, node := range opt.Nodes {
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			filePath, err := collectNodeDiagnostics(opt, node, dir)
+			if err != nil {
+				log.Error("Error collecting diagnostics of %s: %v", node, err)
+				return
+			}
+			log.Info("Saved diagnostics of %s to %s", node, filePath)
+		}(node)
+	}
+	wg.Wait()
+}
+
+func collectNodeDiagnostics(opt *gorgon.Options, node, dir string) (string, error) {
+	client, err := jrpc.Dial(fmt.Sprintf("%s:%d", node, opt.RpcPort), []byte(opt.RpcPassword))
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	var reply rpcs.DiagnosticsReply
+	err = callTimeout(client, "DiagnosticsRpc.Collect", &rpcs.DiagnosticsArgs{Timeout: diagnosticsTimeout}, &reply,
+		collectTimeout)
+	if err != nil {
+		return "", err
+	}
+	for _, msg := range reply.Errors {
+		log.Warning("Diagnostics of %s: %s", node, msg)
+	}
+	filePath := path.Join(dir, EscapeFileName(fmt.Sprintf("diagnostics.%s.tar.gz", node)))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	offset := int64(0)
+	for {
+		var chunk rpcs.DiagnosticsChunk
+		args := &rpcs.DiagnosticsFetchArgs{Archive: reply.Archive, Offset: offset}
+		err = callTimeout(client, "DiagnosticsRpc.Fetch", args, &chunk, fetchTimeout)
+		if err != nil || len(chunk.Data) == 0 {
+			break
+		}
+		if _, err = file.Write(chunk.Data); err != nil {
+			break
+		}
+		offset += int64(len(chunk.Data))
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", err
+	}
+	return filePath, nil
+}
+
+// callTimeout calls method like client.Call, but gives up after timeout.
+func callTimeout(client *rpc.Client, method string, args, reply interface{}, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case call := <-client.Go(method, args, reply, make(chan *rpc.Call, 1)).Done:
+		return call.Error
+	case <-timer.C:
+		return fmt.Errorf("%s timed out after %v", method, timeout)
+	}
+}
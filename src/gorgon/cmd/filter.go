@@ -7,11 +7,15 @@ import (
 )
 
 type Filter struct {
-	match   []wildcard.Matcher
-	exclude []wildcard.Matcher
+	match          []wildcard.Matcher
+	exclude        []wildcard.Matcher
+	matchPattern   string
+	excludePattern string
 }
 
 func MakeFilter(match, exclude string) (filter Filter) {
+	filter.matchPattern = match
+	filter.excludePattern = exclude
 	for _, p := range strings.Split(match, "|") {
 		filter.match = append(filter.match, wildcard.Compile(p))
 	}
@@ -23,6 +27,10 @@ func MakeFilter(match, exclude string) (filter Filter) {
 	return
 }
 
+func (filter Filter) Patterns() (match, exclude string) {
+	return filter.matchPattern, filter.excludePattern
+}
+
 func (filter Filter) Match(subject string) bool {
 	matched := false
 	for _, m := range filter.match {
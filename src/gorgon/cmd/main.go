@@ -1,18 +1,30 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path"
+	"runtime"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/history"
 	"github.com/pavlosg/gorgon/src/gorgon/jrpc"
 	"github.com/pavlosg/gorgon/src/gorgon/log"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
 )
 
-const exitUsage = 2
+const (
+	exitUsage   = 2
+	exitIllegal = 3
+	exitUnknown = 4
+)
 
 func Main(db gorgon.Database) int {
 	var filter Filter
@@ -20,6 +32,13 @@ func Main(db gorgon.Database) int {
 		WorkloadDuration: time.Minute,
 		Concurrency:      6,
 		RpcPort:          9090,
+		CheckTimeout:     40 * time.Second,
+		CheckWorkers:     runtime.NumCPU(),
+		OutputDir:        ".",
+		Repeat:           1,
+		QuiesceDuration:  10 * time.Second,
+		Schedule:         "constant",
+		InFlight:         16,
 	}
 	ret := parseOptions(opt, &filter)
 	if ret != 0 {
@@ -32,6 +51,10 @@ func Main(db gorgon.Database) int {
 	switch flag.Arg(0) {
 	case "run":
 		return cmdRun(db, opt, &filter)
+	case "check":
+		return cmdCheck(db, opt)
+	case "list":
+		return cmdList(db, opt, &filter)
 	case "rpc":
 		return cmdRpc(opt)
 	}
@@ -39,36 +62,220 @@ func Main(db gorgon.Database) int {
 }
 
 func usage() int {
-	fmt.Println("Usage:", os.Args[0], "[options] run|rpc [args...]")
+	fmt.Println("Usage:", os.Args[0], "[options] run|check|list|rpc [args...]")
 	return exitUsage
 }
 
 func cmdRun(db gorgon.Database, opt *gorgon.Options, filter *Filter) int {
+	runDir, err := makeRunDir(opt.OutputDir, time.Now())
+	if err != nil {
+		log.Error("Error creating output directory: %v", err)
+		return 1
+	}
+	closeLog, err := teeLogToFile(path.Join(runDir, "gorgon.log"))
+	if err != nil {
+		log.Error("Error creating log file: %v", err)
+		return 1
+	}
+	defer closeLog()
+	log.Info("Running with -gorgon-seed %d, artifacts in %s", opt.Seed, runDir)
+	report := NewReport(db, opt, filter)
+	ret := runIterations(db, opt, filter, runDir, report)
+	report.PrintSummary(os.Stdout)
+	if ret != 0 {
+		return ret
+	}
+	return report.ExitCode()
+}
+
+// runIterations runs the selected workloads as many times as requested by
+// -gorgon-repeat and -gorgon-soak-duration. The report and summary in runDir
+// are rewritten after every iteration.
+func runIterations(db gorgon.Database, opt *gorgon.Options, filter *Filter, runDir string, report *Report) int {
+	var deadline time.Time
+	if opt.SoakDuration > 0 {
+		deadline = time.Now().Add(opt.SoakDuration)
+	}
+	for i := 0; opt.Repeat == 0 || i < opt.Repeat; i++ {
+		if i > 0 && !deadline.IsZero() && time.Now().After(deadline) {
+			log.Info("Soak duration %v reached after %d iterations", opt.SoakDuration, i)
+			break
+		}
+		iterOpt := *opt
+		dir := runDir
+		if i > 0 {
+			iterOpt.Seed = splitmix.Derive(opt.Seed, i)
+		}
+		if opt.Repeat != 1 {
+			dir = path.Join(runDir, fmt.Sprintf("%04d", i))
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				log.Error("Error creating output directory: %v", err)
+				return 1
+			}
+			log.Info("Iteration %d with -gorgon-seed %d", i, iterOpt.Seed)
+		}
+		report.Iterations = i + 1
+		ret, violation := runWorkloads(db, &iterOpt, filter, dir, report, i)
+		writeReport(report, runDir)
+		if ret != 0 {
+			return ret
+		}
+		if violation && opt.StopOnViolation {
+			log.Warning("Stopping after violation in iteration %d", i)
+			break
+		}
+	}
+	return 0
+}
+
+func writeReport(report *Report, runDir string) {
+	report.End = time.Now()
+	if err := report.WriteSummaryFile(path.Join(runDir, "summary.txt")); err != nil {
+		log.Error("Error writing summary: %v", err)
+	}
+	if err := report.WriteFile(path.Join(runDir, "report.json")); err != nil {
+		log.Error("Error writing report: %v", err)
+	}
+}
+
+func runWorkloads(db gorgon.Database, opt *gorgon.Options, filter *Filter, runDir string, report *Report,
+	iteration int) (ret int, violation bool) {
 	workloads := db.Workloads()
 	for _, workload := range workloads {
 		runner := NewRunner(db, workload, opt)
 		if !filter.Match(runner.Name()) {
 			continue
 		}
+		rr := report.AddRunner(runner)
+		rr.Iteration = iteration
+		rr.Seed = opt.Seed
+		dir, err := makeWorkloadDir(runDir, runner)
+		if err != nil {
+			log.Error("Error creating output directory: %v", err)
+			rr.SetError(err)
+			return 1, violation
+		}
 		if err := runner.SetUp(); err != nil {
 			log.Error("Error in Runner.SetUp: %v", err)
-			return 1
+			rr.SetError(err)
+			collectDiagnostics(opt, dir)
+			return 1, violation
 		}
 		history, err := runner.Run()
+		rr.SetHistory(runner.Start(), history)
+		if _, saveErr := runner.SaveHistory(history, dir); saveErr != nil {
+			log.Error("Error in Runner.SaveHistory: %v", saveErr)
+		}
+		if _, statsErr := runner.SaveStats(history, dir); statsErr != nil {
+			log.Error("Error in Runner.SaveStats: %v", statsErr)
+		}
+		if _, timelineErr := runner.SaveTimeline(history, dir); timelineErr != nil {
+			log.Error("Error in Runner.SaveTimeline: %v", timelineErr)
+		}
 		if err != nil {
-			return 1
+			rr.SetError(err)
+			collectDiagnostics(opt, dir)
+			return 1, violation
 		}
 		if err := runner.TearDown(); err != nil {
 			log.Error("Error in Runner.TearDown: %v", err)
 		}
-		if err := runner.Check(history, ""); err != nil {
+		check, err := checkInterruptible(runner, history, dir)
+		rr.SetCheck(check)
+		if err == errInterrupted {
+			log.Warning("[%s] Check interrupted, skipping the remaining workloads", runner.Name())
+			rr.SetError(err)
+			return 1, violation
+		}
+		if err != nil {
+			log.Error("Error in Runner.Check: %v", err)
+			rr.SetError(err)
+			collectDiagnostics(opt, dir)
+			return 1, violation
+		}
+		if rr.Result == gorgon.CheckIllegal {
+			violation = true
+			collectDiagnostics(opt, dir)
+			if opt.StopOnViolation {
+				return 0, violation
+			}
+		}
+	}
+	return 0, violation
+}
+
+func cmdCheck(db gorgon.Database, opt *gorgon.Options) int {
+	if len(opt.Args) != 1 {
+		fmt.Println("Usage:", os.Args[0], "[options] check <history-file>")
+		return exitUsage
+	}
+	header, hist, err := history.ReadFile(opt.Args[0])
+	if err != nil {
+		log.Error("Error reading history: %v", err)
+		return 1
+	}
+	for _, workload := range db.Workloads() {
+		runner := NewRunner(db, workload, opt)
+		if runner.Name() != header.Runner {
+			continue
+		}
+		log.Info("[%s] Checking %d operations from %s (seed %d)", runner.Name(), len(hist), opt.Args[0], header.Seed)
+		check, err := checkInterruptible(runner, hist, path.Dir(opt.Args[0]))
+		report := NewReport(db, opt, nil)
+		rr := report.AddRunner(runner)
+		rr.SetHistory(header.Time, hist)
+		rr.SetCheck(check)
+		rr.SetError(err)
+		report.PrintSummary(os.Stdout)
+		if err != nil {
 			log.Error("Error in Runner.Check: %v", err)
 			return 1
 		}
+		return report.ExitCode()
+	}
+	log.Error("No workload named %q", header.Runner)
+	return 1
+}
+
+func cmdList(db gorgon.Database, opt *gorgon.Options, filter *Filter) int {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SELECTED\tWORKLOAD")
+	selected := 0
+	workloads := db.Workloads()
+	for _, workload := range workloads {
+		name := NewRunner(db, workload, opt).Name()
+		mark := "-"
+		if filter.Match(name) {
+			mark = "yes"
+			selected++
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", mark, name)
 	}
+	if err := tw.Flush(); err != nil {
+		log.Error("Error writing list: %v", err)
+		return 1
+	}
+	match, exclude := filter.Patterns()
+	fmt.Printf("%d of %d workloads selected by -gorgon-match %q -gorgon-exclude %q\n",
+		selected, len(workloads), match, exclude)
 	return 0
 }
 
+var errInterrupted = errors.New("check interrupted")
+
+// checkInterruptible runs Runner.Check so that an interrupt cancels the check
+// instead of terminating the process. It returns errInterrupted, along with
+// the partial report, if the check was interrupted.
+func checkInterruptible(runner *Runner, history []gorgon.Operation, dir string) (*CheckReport, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := runner.Check(ctx, history, dir)
+	if err == nil && ctx.Err() != nil {
+		err = errInterrupted
+	}
+	return report, err
+}
+
 func cmdRpc(opt *gorgon.Options) int {
 	err := jrpc.Listen(fmt.Sprintf(":%v", opt.RpcPort), []byte(opt.RpcPassword))
 	if err != nil {
@@ -82,6 +289,7 @@ func parseOptions(opt *gorgon.Options, filter *Filter) int {
 	matchPattern := "*"
 	excludePattern := ""
 	nodes := "localhost"
+	seed := splitmix.NewSeed()
 
 	flag.StringVar(&matchPattern, "gorgon-match", matchPattern, "Wildcard pattern for scenarios to run")
 	flag.StringVar(&excludePattern, "gorgon-exclude", excludePattern, "Wildcard pattern for scenarios to exclude")
@@ -92,6 +300,23 @@ func parseOptions(opt *gorgon.Options, filter *Filter) int {
 		"Don't stop a worker when its client returns an error that is not unambiguous")
 	flag.IntVar(&opt.RpcPort, "gorgon-rpc-port", opt.RpcPort, "RPC port to connect")
 	flag.StringVar(&opt.RpcPassword, "gorgon-rpc-password", opt.RpcPassword, "RPC password")
+	flag.DurationVar(&opt.CheckTimeout, "gorgon-check-timeout", opt.CheckTimeout,
+		"Linearizability check timeout per partition, 0 for none")
+	flag.IntVar(&opt.CheckWorkers, "gorgon-check-workers", opt.CheckWorkers, "Number of partitions to check in parallel")
+	flag.StringVar(&opt.OutputDir, "gorgon-output-dir", opt.OutputDir, "Directory for the artifacts of each run")
+	flag.IntVar(&opt.Repeat, "gorgon-repeat", opt.Repeat,
+		"Number of iterations of the selected workloads, 0 for unlimited (default unlimited with -gorgon-soak-duration)")
+	flag.DurationVar(&opt.SoakDuration, "gorgon-soak-duration", opt.SoakDuration,
+		"Keep starting new iterations of the selected workloads until this duration has passed")
+	flag.BoolVar(&opt.StopOnViolation, "gorgon-stop-on-violation", false, "Stop iterating at the first illegal history")
+	flag.DurationVar(&opt.QuiesceDuration, "gorgon-quiesce-duration", opt.QuiesceDuration,
+		"Time to wait after healing the nemeses, before the final reads")
+	flag.Float64Var(&opt.Rate, "gorgon-rate", opt.Rate,
+		"Open-loop rate in instructions per second across all clients, 0 for closed-loop")
+	flag.StringVar(&opt.Schedule, "gorgon-schedule", opt.Schedule,
+		"Open-loop arrival schedule: "+strings.Join(scheduleNames(), ", "))
+	flag.IntVar(&opt.InFlight, "gorgon-in-flight", opt.InFlight, "Maximum open-loop instructions in flight per client")
+	flag.Int64Var(&seed, "gorgon-seed", seed, "Master seed for generators and nemeses (random by default)")
 
 	flag.Parse()
 	if flag.NArg() == 0 {
@@ -99,6 +324,17 @@ func parseOptions(opt *gorgon.Options, filter *Filter) int {
 	}
 
 	opt.Args = flag.Args()[1:]
+	repeatSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "gorgon-repeat" {
+			repeatSet = true
+		}
+	})
+	if opt.SoakDuration > 0 && !repeatSet {
+		opt.Repeat = 0
+	}
+	opt.Seed = seed
+	splitmix.Rand.Seed(seed)
 
 	*filter = MakeFilter(matchPattern, excludePattern)
 	if opt.Concurrency < 1 {
@@ -109,6 +345,34 @@ func parseOptions(opt *gorgon.Options, filter *Filter) int {
 		fmt.Println("Invalid port", opt.RpcPort)
 		return exitUsage
 	}
+	if opt.Repeat < 0 || (opt.Repeat == 0 && opt.SoakDuration <= 0) {
+		fmt.Println("Invalid repeat", opt.Repeat, "with soak duration", opt.SoakDuration)
+		return exitUsage
+	}
+	if opt.CheckWorkers < 1 {
+		fmt.Println("Invalid number of check workers", opt.CheckWorkers)
+		return exitUsage
+	}
+	if opt.CheckTimeout < 0 {
+		fmt.Println("Invalid check timeout", opt.CheckTimeout)
+		return exitUsage
+	}
+	if opt.QuiesceDuration < 0 {
+		fmt.Println("Invalid quiesce duration", opt.QuiesceDuration)
+		return exitUsage
+	}
+	if opt.Rate < 0 {
+		fmt.Println("Invalid rate", opt.Rate)
+		return exitUsage
+	}
+	if schedules[opt.Schedule] == nil {
+		fmt.Println("Invalid schedule", opt.Schedule)
+		return exitUsage
+	}
+	if opt.InFlight < 1 {
+		fmt.Println("Invalid number of instructions in flight", opt.InFlight)
+		return exitUsage
+	}
 	if opt.WorkloadDuration < 10*time.Second {
 		fmt.Println("Minimum workload duration 10s")
 		return exitUsage
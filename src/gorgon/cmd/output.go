@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon/log"
+)
+
+// makeRunDir creates a new directory for the artifacts of a run under base.
+func makeRunDir(base string, now time.Time) (string, error) {
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", err
+	}
+	name := now.Format(fileTime)
+	for i := 1; ; i++ {
+		dir := path.Join(base, name)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		name = fmt.Sprintf("%s-%d", now.Format(fileTime), i)
+	}
+}
+
+// makeWorkloadDir creates the directory for the artifacts of runner under
+// the run directory.
+func makeWorkloadDir(runDir string, runner *Runner) (string, error) {
+	dir := path.Join(runDir, EscapeFileName(runner.Name()))
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// teeLogToFile copies all log messages into a new file at filePath until the
+// returned function is called.
+func teeLogToFile(filePath string) (func(), error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	prev := log.GetLogger()
+	log.SetLogger(log.Tee(prev, log.NewWriterLogger(file)))
+	return func() {
+		log.SetLogger(prev)
+		file.Close()
+	}, nil
+}
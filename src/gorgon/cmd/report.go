@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/stats"
+)
+
+// CheckReport is the result of Runner.Check.
+type CheckReport struct {
+	Name     string
+	Checkers []CheckerReport
+}
+
+type CheckerReport struct {
+	Checker    string
+	Partitions []gorgon.PartitionResult
+	Error      string `json:",omitempty"`
+}
+
+// Count returns the number of partitions with the given result over all
+// checkers.
+func (report *CheckReport) Count(result gorgon.CheckResult) int {
+	n := 0
+	for i := range report.Checkers {
+		n += report.Checkers[i].Count(result)
+	}
+	return n
+}
+
+// Result returns illegal if any partition is illegal, otherwise unknown if
+// any partition is unknown, otherwise ok.
+func (report *CheckReport) Result() gorgon.CheckResult {
+	return combineResults(report.Count(gorgon.CheckIllegal), report.Count(gorgon.CheckUnknown))
+}
+
+func (report *CheckerReport) Count(result gorgon.CheckResult) int {
+	n := 0
+	for _, part := range report.Partitions {
+		if part.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+func (report *CheckerReport) Result() gorgon.CheckResult {
+	return combineResults(report.Count(gorgon.CheckIllegal), report.Count(gorgon.CheckUnknown))
+}
+
+func (report *CheckerReport) Operations() int {
+	n := 0
+	for _, part := range report.Partitions {
+		n += part.Operations
+	}
+	return n
+}
+
+func combineResults(illegal, unknown int) gorgon.CheckResult {
+	if illegal != 0 {
+		return gorgon.CheckIllegal
+	}
+	if unknown != 0 {
+		return gorgon.CheckUnknown
+	}
+	return gorgon.CheckOk
+}
+
+// Report describes one invocation of a command and the outcome of every
+// workload it ran. It is written as report.json into the run directory.
+type Report struct {
+	Database   string
+	Start      time.Time
+	End        time.Time
+	Options    ReportOptions
+	Iterations int
+	Runners    []*RunnerReport
+}
+
+type ReportOptions struct {
+	Args                    []string
+	Nodes                   []string
+	Concurrency             int
+	WorkloadDuration        string
+	ContinueAmbiguousClient bool
+	Match                   string
+	Exclude                 string
+	Seed                    int64
+	CheckTimeout            string
+	Repeat                  int
+	SoakDuration            string
+	StopOnViolation         bool
+	QuiesceDuration         string
+	Rate                    float64 `json:",omitempty"`
+	Schedule                string  `json:",omitempty"`
+	InFlight                int     `json:",omitempty"`
+}
+
+type RunnerReport struct {
+	Name          string
+	Iteration     int
+	Seed          int64
+	Generators    []string
+	Start         time.Time
+	Error         string                     `json:",omitempty"`
+	Operations    map[string]OperationCounts // by instruction type
+	Total         OperationCounts
+	NemesisEvents []NemesisEvent
+	Result        gorgon.CheckResult `json:",omitempty"`
+	Check         *CheckReport       `json:",omitempty"`
+}
+
+type OperationCounts struct {
+	Ok          int
+	Ambiguous   int // failed with an error that is not unambiguous
+	Unambiguous int // failed with an unambiguous error
+}
+
+type NemesisEvent struct {
+	Instruction string
+	Call        int64 // microseconds since the start of the run
+	Return      int64
+	Time        time.Time
+	Output      string `json:",omitempty"`
+	Error       bool
+}
+
+func NewReport(db gorgon.Database, opt *gorgon.Options, filter *Filter) *Report {
+	report := &Report{Database: db.Name(), Start: time.Now()}
+	report.Options = ReportOptions{
+		Args:                    opt.Args,
+		Nodes:                   opt.Nodes,
+		Concurrency:             opt.Concurrency,
+		WorkloadDuration:        opt.WorkloadDuration.String(),
+		ContinueAmbiguousClient: opt.ContinueAmbiguousClient,
+		Seed:                    opt.Seed,
+		CheckTimeout:            opt.CheckTimeout.String(),
+		Repeat:                  opt.Repeat,
+		SoakDuration:            opt.SoakDuration.String(),
+		StopOnViolation:         opt.StopOnViolation,
+		QuiesceDuration:         opt.QuiesceDuration.String(),
+	}
+	if opt.Rate > 0 {
+		report.Options.Rate = opt.Rate
+		report.Options.Schedule = opt.Schedule
+		report.Options.InFlight = opt.InFlight
+	}
+	if filter != nil {
+		report.Options.Match, report.Options.Exclude = filter.Patterns()
+	}
+	return report
+}
+
+// AddRunner adds a report for runner. The history and check report may be
+// added later with SetHistory and SetCheck.
+func (report *Report) AddRunner(runner *Runner) *RunnerReport {
+	rr := &RunnerReport{Name: runner.Name(), Operations: make(map[string]OperationCounts)}
+	for _, gen := range runner.workload.Generators {
+		rr.Generators = append(rr.Generators, gen.Name())
+	}
+	report.Runners = append(report.Runners, rr)
+	return rr
+}
+
+func (rr *RunnerReport) SetError(err error) {
+	if err != nil && len(rr.Error) == 0 {
+		rr.Error = err.Error()
+	}
+}
+
+// SetHistory counts the client operations of history by instruction type and
+// lists its nemesis events.
+func (rr *RunnerReport) SetHistory(start time.Time, history []gorgon.Operation) {
+	rr.Start = start
+	for _, op := range gorgon.ClientOperations(history) {
+		name := stats.InstructionTypeName(op.Input)
+		counts := rr.Operations[name]
+		counts.add(op.Output)
+		rr.Operations[name] = counts
+		rr.Total.add(op.Output)
+	}
+	for _, op := range gorgon.NemesisOperations(history) {
+		event := NemesisEvent{
+			Instruction: op.Input.String(),
+			Call:        op.Call,
+			Return:      op.Return,
+			Time:        start.Add(time.Duration(op.Call) * time.Microsecond),
+		}
+		if err, ok := op.Output.(error); ok {
+			event.Error = true
+			event.Output = err.Error()
+		} else if op.Output != nil {
+			event.Output = fmt.Sprint(op.Output)
+		}
+		rr.NemesisEvents = append(rr.NemesisEvents, event)
+	}
+}
+
+func (rr *RunnerReport) SetCheck(check *CheckReport) {
+	rr.Check = check
+	if check != nil {
+		rr.Result = check.Result()
+	}
+}
+
+func (counts *OperationCounts) add(output gorgon.Output) {
+	if err, ok := output.(error); ok {
+		if gorgon.IsUnambiguousError(err) {
+			counts.Unambiguous++
+		} else {
+			counts.Ambiguous++
+		}
+	} else {
+		counts.Ok++
+	}
+}
+
+// ExitCode returns exitIllegal if any workload was not linearizable,
+// otherwise exitUnknown if any check was inconclusive, otherwise 0.
+func (report *Report) ExitCode() int {
+	unknown := false
+	for _, rr := range report.Runners {
+		switch rr.Result {
+		case gorgon.CheckIllegal:
+			return exitIllegal
+		case gorgon.CheckUnknown:
+			unknown = true
+		}
+	}
+	if unknown {
+		return exitUnknown
+	}
+	return 0
+}
+
+func (report *Report) WriteFile(filePath string) error {
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, append(bytes, '\n'), 0644)
+}
+
+func (report *Report) WriteSummaryFile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	err = report.PrintSummary(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// PrintSummary writes a table with the outcome of every workload, followed by
+// the visualizations of the partitions that are not ok. When the workloads ran
+// in more than one iteration, it also writes the tally of every workload.
+func (report *Report) PrintSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ITERATION\tWORKLOAD\tCHECKER\tRESULT\tPARTITIONS\tILLEGAL\tUNKNOWN\tOPERATIONS")
+	for _, rr := range report.Runners {
+		if rr.Check == nil {
+			fmt.Fprintf(tw, "%d\t%s\t-\terror\t-\t-\t-\t-\n", rr.Iteration, rr.Name)
+			continue
+		}
+		for i := range rr.Check.Checkers {
+			cr := &rr.Check.Checkers[i]
+			result := string(cr.Result())
+			if len(cr.Error) != 0 {
+				result = "error"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n", rr.Iteration, rr.Name, cr.Checker, result,
+				len(cr.Partitions), cr.Count(gorgon.CheckIllegal), cr.Count(gorgon.CheckUnknown), cr.Operations())
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, rr := range report.Runners {
+		if len(rr.Error) != 0 {
+			fmt.Fprintf(w, "%s (iteration %d): %s\n", rr.Name, rr.Iteration, rr.Error)
+		}
+		if rr.Check == nil {
+			continue
+		}
+		for _, cr := range rr.Check.Checkers {
+			for _, part := range cr.Partitions {
+				if part.Result != gorgon.CheckOk && len(part.Visualization) != 0 {
+					fmt.Fprintf(w, "%s %s partition %d %s: %s\n",
+						rr.Name, cr.Checker, part.Index, part.Result, part.Visualization)
+				}
+			}
+		}
+	}
+	if report.Iterations > 1 {
+		fmt.Fprintln(w)
+		return report.PrintTally(w)
+	}
+	return nil
+}
+
+// PrintTally writes, for every workload, the number of iterations, violations
+// and the rate of ambiguous operations over all iterations.
+func (report *Report) PrintTally(w io.Writer) error {
+	type tally struct {
+		iterations, illegal, unknown, errors int
+		counts                               OperationCounts
+	}
+	var names []string
+	tallies := make(map[string]*tally)
+	for _, rr := range report.Runners {
+		t, ok := tallies[rr.Name]
+		if !ok {
+			t = &tally{}
+			tallies[rr.Name] = t
+			names = append(names, rr.Name)
+		}
+		t.iterations++
+		switch {
+		case len(rr.Error) != 0:
+			t.errors++
+		case rr.Result == gorgon.CheckIllegal:
+			t.illegal++
+		case rr.Result == gorgon.CheckUnknown:
+			t.unknown++
+		}
+		t.counts.Ok += rr.Total.Ok
+		t.counts.Ambiguous += rr.Total.Ambiguous
+		t.counts.Unambiguous += rr.Total.Unambiguous
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKLOAD\tITERATIONS\tVIOLATIONS\tUNKNOWN\tERRORS\tOPERATIONS\tAMBIGUOUS")
+	for _, name := range names {
+		t := tallies[name]
+		ops := t.counts.Ok + t.counts.Ambiguous + t.counts.Unambiguous
+		rate := 0.0
+		if ops != 0 {
+			rate = float64(t.counts.Ambiguous) * 100 / float64(ops)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%.3f%%\n",
+			name, t.iterations, t.illegal, t.unknown, t.errors, ops, rate)
+	}
+	return tw.Flush()
+}
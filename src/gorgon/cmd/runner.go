@@ -1,25 +1,38 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/anishathalye/porcupine"
 	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/checkers"
+	"github.com/pavlosg/gorgon/src/gorgon/history"
 	"github.com/pavlosg/gorgon/src/gorgon/log"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+	"github.com/pavlosg/gorgon/src/gorgon/stats"
+	"github.com/pavlosg/gorgon/src/gorgon/timeline"
 )
 
+const fileTime = "2006-01-02-150405-0700"
+
+// statsBucket is the time window in which throughput is counted.
+const statsBucket = time.Second
+
 type Runner struct {
 	name     string
 	db       gorgon.Database
 	workload gorgon.Workload
 	options  *gorgon.Options
 	clients  []gorgon.Client
+	start    time.Time
 }
 
 func NewRunner(db gorgon.Database, workload gorgon.Workload, opts *gorgon.Options) *Runner {
@@ -29,13 +42,19 @@ func NewRunner(db gorgon.Database, workload gorgon.Workload, opts *gorgon.Option
 		sb.WriteByte('~')
 		sb.WriteString(gen.Name())
 	}
-	return &Runner{sb.String(), db, workload, opts, nil}
+	return &Runner{name: sb.String(), db: db, workload: workload, options: opts}
 }
 
 func (runner *Runner) Name() string {
 	return runner.name
 }
 
+// Start returns the time at which the last Run started; operation timestamps
+// are microseconds since then.
+func (runner *Runner) Start() time.Time {
+	return runner.start
+}
+
 func (runner *Runner) SetUp() error {
 	log.Info("[%s] Database SetUp", runner.name)
 	if err := runner.db.SetUp(); err != nil {
@@ -70,9 +89,11 @@ func (runner *Runner) SetUp() error {
 		}
 		clients[i] = client
 	}
-	log.Info("[%s] Workload SetUp", runner.name)
-	for _, gen := range runner.workload.Generators {
-		if err := gen.SetUp(runner.options); err != nil {
+	log.Info("[%s] Workload SetUp with seed %d", runner.name, runner.options.Seed)
+	for i, gen := range runner.generators() {
+		genOpt := *runner.options
+		genOpt.Seed = splitmix.Derive(runner.options.Seed, i)
+		if err := gen.SetUp(&genOpt); err != nil {
 			log.Error("[%s] Error in Generator.SetUp: %v", runner.name, err)
 			return err
 		}
@@ -89,8 +110,16 @@ func (runner *Runner) Run() ([]gorgon.Operation, error) {
 	genMutex := &sync.Mutex{}
 	operationList := gorgon.NewOperationList()
 	concurrency := runner.options.Concurrency
-	log.Info("[%s] Starting workers", runner.name)
+	if runner.options.Rate > 0 {
+		log.Info("[%s] Starting workers at %v instructions/s with %s schedule", runner.name,
+			runner.options.Rate, runner.options.Schedule)
+	} else {
+		log.Info("[%s] Starting workers", runner.name)
+	}
+	runner.start = time.Now()
 	deadline := time.Now().Add(runner.options.WorkloadDuration)
+	workers := make([]*worker, 0, concurrency+1)
+	scheduleSeed := splitmix.Derive(runner.options.Seed, len(runner.generators()))
 	for i := -1; i < concurrency; i++ {
 		var client gorgon.Client
 		if i >= 0 {
@@ -107,16 +136,111 @@ func (runner *Runner) Run() ([]gorgon.Operation, error) {
 			stopAmbiguous: !runner.options.ContinueAmbiguousClient,
 			name:          runner.name,
 		}
+		if client != nil && runner.options.Rate > 0 {
+			r := splitmix.NewRandSeed(splitmix.Derive(scheduleSeed, i))
+			rate := runner.options.Rate / float64(concurrency)
+			w.schedule = schedules[runner.options.Schedule](rate, runner.options.WorkloadDuration, r)
+			w.inFlight = runner.options.InFlight
+		}
+		workers = append(workers, w)
 		wg.Add(1)
 		go w.run()
 	}
 	wg.Wait()
 	log.Info("[%s] Workers finished", runner.name)
+	runner.heal(operationList)
+	if len(runner.workload.Final) != 0 {
+		if d := runner.options.QuiesceDuration; d > 0 {
+			log.Info("[%s] Quiescing for %v", runner.name, d)
+			time.Sleep(d)
+		}
+		if err := runner.final(workers); err != nil {
+			return operationList.Extract(), err
+		}
+	}
 	return operationList.Extract(), nil
 }
 
-func (runner *Runner) TearDown() (retErr error) {
+// heal invokes the instructions that undo the faults of the generators that
+// are Healers, and appends them to operations as nemesis events.
+func (runner *Runner) heal(operations *gorgon.OperationList) {
 	for _, gen := range runner.workload.Generators {
+		healer, ok := gen.(gorgon.Healer)
+		if !ok {
+			continue
+		}
+		for instr := healer.Heal(); instr != nil; instr = healer.Heal() {
+			log.Info("[%s] Healing %s: %s", runner.name, gen.Name(), instr)
+			event := invokeNemesis(-1, instr, gen, operations.GetTime)
+			if err, ok := event.Output.(error); ok {
+				log.Error("[%s] Error healing %s: %v", runner.name, gen.Name(), err)
+			}
+			operations.Append(event)
+		}
+	}
+}
+
+// final runs the final generators of the workload, closed-loop, on the
+// clients of the workers. Workers that stopped on an ambiguous error get a
+// fresh client, with a new id, so that the final phase still reads from every
+// worker. It fails if no client could run the final phase.
+func (runner *Runner) final(workers []*worker) error {
+	wg := &sync.WaitGroup{}
+	deadline := time.Now().Add(runner.options.WorkloadDuration)
+	n := 0
+	for _, prev := range workers {
+		if prev.client == nil {
+			continue
+		}
+		client := prev.client
+		if prev.stoppedAmbiguous.Load() {
+			var err error
+			if client, err = runner.freshClient(client.Id()); err != nil {
+				log.Error("[%s] Error opening a fresh client for the final phase: %v", runner.name, err)
+				continue
+			}
+		}
+		w := &worker{
+			stopFlag:      prev.stopFlag,
+			wg:            wg,
+			genMutex:      prev.genMutex,
+			generators:    runner.workload.Final,
+			client:        client,
+			operations:    prev.operations,
+			deadline:      deadline,
+			stopAmbiguous: prev.stopAmbiguous,
+			name:          prev.name,
+		}
+		wg.Add(1)
+		go w.run()
+		n++
+	}
+	if n == 0 {
+		return errors.New("no clients for the final phase")
+	}
+	log.Info("[%s] Final phase with %d clients", runner.name, n)
+	wg.Wait()
+	log.Info("[%s] Final phase finished", runner.name)
+	return nil
+}
+
+// freshClient opens a client in place of client id, which stopped on an
+// ambiguous error, and keeps it to be closed by TearDown.
+func (runner *Runner) freshClient(id int) (gorgon.Client, error) {
+	client, err := runner.db.NewClient(id + runner.options.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Open(runner.db.ClientConfig()); err != nil {
+		return nil, err
+	}
+	log.Info("[%s] Opened client %d in place of client %d", runner.name, client.Id(), id)
+	runner.clients = append(runner.clients, client)
+	return client, nil
+}
+
+func (runner *Runner) TearDown() (retErr error) {
+	for _, gen := range runner.generators() {
 		if err := gen.TearDown(); err != nil {
 			log.Error("[%s] Error in Generator.TearDown: %v", runner.name, err)
 			if retErr == nil {
@@ -138,47 +262,133 @@ func (runner *Runner) TearDown() (retErr error) {
 	return
 }
 
-func (runner *Runner) Check(history []gorgon.Operation, dir string) (err error) {
-	const fileTime = "2006-01-02-150405-0700"
-	model := runner.workload.Model
-	ndmodel := porcupine.NondeterministicModel{
-		Init: model.Init,
-		Step: func(state, input, output interface{}) []interface{} {
-			return model.Step(state, input.(gorgon.Instruction), output)
-		},
-		Equal: model.Equal,
-		DescribeOperation: func(input, output interface{}) string {
-			return model.DescribeOperation(input.(gorgon.Instruction), output)
-		},
-		DescribeState: model.DescribeState,
+// SaveHistory writes history in JSON Lines format into dir and returns the
+// path of the file.
+func (runner *Runner) SaveHistory(hist []gorgon.Operation, dir string) (string, error) {
+	start := runner.start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	filePath := path.Join(dir, EscapeFileName(fmt.Sprintf(
+		"%s.%s.jsonl", start.Format(fileTime), runner.name)))
+	header := history.Header{Runner: runner.name, Time: start, Seed: runner.options.Seed}
+	if err := history.WriteFile(filePath, header, hist); err != nil {
+		return "", err
+	}
+	log.Info("[%s] Saved history of %d operations to %s", runner.name, len(hist), filePath)
+	return filePath, nil
+}
+
+// SaveStats computes the latency and throughput statistics of hist, logs
+// their percentiles and writes them into dir as JSON and CSV files.
+func (runner *Runner) SaveStats(hist []gorgon.Operation, dir string) (*stats.Stats, error) {
+	st := stats.Compute(hist, statsBucket)
+	for _, line := range st.Lines() {
+		log.Info("[%s] %s", runner.name, line)
+	}
+	start := runner.start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	for _, file := range []struct {
+		suffix string
+		write  func(io.Writer) error
+	}{
+		{"stats.json", st.WriteJSON},
+		{"latency.csv", st.WriteLatencyCSV},
+		{"throughput.csv", st.WriteThroughputCSV},
+	} {
+		filePath := path.Join(dir, EscapeFileName(fmt.Sprintf(
+			"%s.%s.%s", start.Format(fileTime), runner.name, file.suffix)))
+		if err := writeFile(filePath, file.write); err != nil {
+			return st, err
+		}
+	}
+	log.Info("[%s] Saved stats to %s", runner.name, dir)
+	return st, nil
+}
+
+// SaveTimeline draws hist into an HTML file in dir and returns the path of the
+// file.
+func (runner *Runner) SaveTimeline(hist []gorgon.Operation, dir string) (string, error) {
+	start := runner.start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	filePath := path.Join(dir, EscapeFileName(fmt.Sprintf(
+		"%s.%s.timeline.html", start.Format(fileTime), runner.name)))
+	title := fmt.Sprintf("%s (seed %d) at %s", runner.name, runner.options.Seed, start.Format(time.RFC3339))
+	if err := timeline.WriteFile(filePath, title, hist); err != nil {
+		return "", err
+	}
+	log.Info("[%s] Saved timeline to %s", runner.name, filePath)
+	return filePath, nil
+}
+
+func writeFile(filePath string, write func(io.Writer) error) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
 	}
-	dmodel := ndmodel.ToModel()
-	partitions := model.Partition(history)
+	return file.Close()
+}
+
+// generators returns the generators of the workload followed by its final
+// generators.
+func (runner *Runner) generators() []gorgon.Generator {
+	ret := append([]gorgon.Generator{}, runner.workload.Generators...)
+	return append(ret, runner.workload.Final...)
+}
+
+func (runner *Runner) checkers() []gorgon.Checker {
+	var ret []gorgon.Checker
+	if runner.workload.Step != nil {
+		ret = append(ret, checkers.Linearizable(runner.workload.Model))
+	}
+	return append(ret, runner.workload.Checkers...)
+}
+
+// Check runs every checker of the workload on history. Checkers write their
+// artifacts, such as visualizations, into dir.
+func (runner *Runner) Check(ctx context.Context, history []gorgon.Operation, dir string) (report *CheckReport, err error) {
 	now := time.Now()
-	for i, part := range partitions {
-		hist := make([]porcupine.Operation, len(part))
-		for i := 0; i < len(part); i++ {
-			op := part[i]
-			hist[i] = porcupine.Operation{
-				ClientId: op.ClientId,
-				Input:    op.Input,
-				Call:     op.Call,
-				Output:   op.Output,
-				Return:   op.Return,
+	opt := &gorgon.CheckOptions{
+		Timeout: runner.options.CheckTimeout,
+		Workers: runner.options.CheckWorkers,
+		ArtifactPath: func(suffix string) string {
+			return path.Join(dir, EscapeFileName(fmt.Sprintf(
+				"%s.%s.%s", now.Format(fileTime), runner.name, suffix)))
+		},
+	}
+	report = &CheckReport{Name: runner.name}
+	history = gorgon.ClientOperations(history)
+	for _, checker := range runner.checkers() {
+		name := checker.Name()
+		results, checkErr := checker.Check(ctx, history, opt)
+		for _, part := range results {
+			level := log.INFO
+			if part.Result != gorgon.CheckOk {
+				level = log.WARNING
+			}
+			if len(part.Details) != 0 {
+				log.Log(level, "[%s] %s partition %d - %s: %s", runner.name, name, part.Index, part.Result, part.Details)
+			} else {
+				log.Log(level, "[%s] %s partition %d - %s", runner.name, name, part.Index, part.Result)
 			}
 		}
-		result, info := porcupine.CheckOperationsVerbose(dmodel, hist, 40*time.Second)
-		level := log.INFO
-		if result != porcupine.Ok {
-			level = log.WARNING
-			filePath := path.Join(dir, EscapeFileName(fmt.Sprintf(
-				"%s.%s.%d.html", now.Format(fileTime), runner.name, i)))
-			visErr := porcupine.VisualizePath(dmodel, info, filePath)
-			if visErr != nil && err == nil {
-				err = visErr
+		checkerReport := CheckerReport{Checker: name, Partitions: results}
+		if checkErr != nil {
+			log.Error("[%s] Checker %s failed: %v", runner.name, name, checkErr)
+			checkerReport.Error = checkErr.Error()
+			if err == nil {
+				err = checkErr
 			}
 		}
-		log.Log(level, "[%s] Checked partition %d - %s", runner.name, i, result)
+		report.Checkers = append(report.Checkers, checkerReport)
 	}
 	return
 }
@@ -193,10 +403,20 @@ type worker struct {
 	deadline      time.Time
 	stopAmbiguous bool
 	name          string
+	// schedule is set for open-loop clients, which keep up to inFlight
+	// instructions in flight
+	schedule schedule
+	inFlight int
+	// stoppedAmbiguous is set if the client stopped on an ambiguous error
+	stoppedAmbiguous atomic.Bool
 }
 
 func (w *worker) run() {
 	defer w.wg.Done()
+	if w.schedule != nil {
+		w.runOpenLoop()
+		return
+	}
 	id := -1
 	if w.client != nil {
 		id = w.client.Id()
@@ -210,50 +430,128 @@ func (w *worker) run() {
 			time.Sleep(time.Millisecond)
 			continue
 		}
+		if !w.execute(id, instr, gen) {
+			return
+		}
+	}
+}
 
-		if instr.ForSelf() {
-			if err := w.onCall(id, instr); err != nil {
-				return
-			}
-			_, output := gen.Invoke(instr, w.operations.GetTime)
-			if err := w.onReturn(id, instr, output); err != nil {
-				return
-			}
+// runOpenLoop asks for an instruction at every arrival of the schedule and
+// invokes it without waiting for the previous ones to return. Arrivals that
+// find inFlight instructions in flight are dropped, and so are those for which
+// the generators have no instruction.
+func (w *worker) runOpenLoop() {
+	id := w.client.Id()
+	pool := make(chan struct{}, w.inFlight)
+	pending := &sync.WaitGroup{}
+	stopped := &atomic.Bool{}
+	start := time.Now()
+	next := start
+	arrivals, dropped, idle := 0, 0, 0
+	for !w.stopFlag.Load() && !stopped.Load() {
+		next = next.Add(w.schedule.interval(next.Sub(start)))
+		if next.After(w.deadline) || time.Now().After(w.deadline) {
+			break
+		}
+		time.Sleep(time.Until(next))
+		arrivals++
+		select {
+		case pool <- struct{}{}:
+		default:
+			dropped++
 			continue
 		}
-
-		if w.client == nil {
-			panic(errors.New("worker has no client assigned"))
+		instr, gen, err := w.getNext(id)
+		if err != nil {
+			<-pool
+			break
 		}
-		if err := w.onCall(id, instr); err != nil {
-			return
+		if instr == nil {
+			idle++
+			<-pool
+			continue
 		}
-		op := gorgon.Operation{ClientId: id, Input: instr, Call: w.operations.GetTime()}
-		retTime, output := w.client.Invoke(instr, w.operations.GetTime)
-		if err := w.onReturn(id, instr, output); err != nil {
-			return
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			if !w.execute(id, instr, gen) {
+				stopped.Store(true)
+			}
+			<-pool
+		}()
+	}
+	pending.Wait()
+	if dropped != 0 {
+		log.Warning("[%s] Client %d dropped %d of %d arrivals with %d instructions in flight",
+			w.name, id, dropped, arrivals, w.inFlight)
+	}
+	if idle != 0 {
+		log.Warning("[%s] Client %d had no instruction for %d of %d arrivals", w.name, id, idle, arrivals)
+	}
+}
+
+// execute invokes instr, which gen returned for client id, and records it.
+// It returns false if the worker should stop.
+func (w *worker) execute(id int, instr gorgon.Instruction, gen gorgon.Generator) bool {
+	if instr.ForSelf() {
+		if err := w.onCall(id, instr); err != nil {
+			return false
 		}
+		event := invokeNemesis(id, instr, gen, w.operations.GetTime)
+		w.operations.Append(event)
+		return w.onReturn(id, instr, event.Output) == nil
+	}
 
-		op.Return = retTime
-		op.Output = output
-		if err, ok := output.(error); ok && !gorgon.IsUnambiguousError(err) {
-			op.Return = -1
-			if w.stopAmbiguous {
-				log.Warning("[%s] Client %d returned ambiguous error: %T %v", w.name, id, err, err)
-				w.operations.Append(op)
-				return
-			}
+	if w.client == nil {
+		panic(errors.New("worker has no client assigned"))
+	}
+	if err := w.onCall(id, instr); err != nil {
+		return false
+	}
+	op := gorgon.Operation{ClientId: id, Input: instr, Call: w.operations.GetTime()}
+	retTime, output := w.client.Invoke(instr, w.operations.GetTime)
+	if err := w.onReturn(id, instr, output); err != nil {
+		return false
+	}
+
+	op.Return = retTime
+	op.Output = output
+	if err, ok := output.(error); ok && !gorgon.IsUnambiguousError(err) {
+		op.Return = -1
+		if w.stopAmbiguous {
+			log.Warning("[%s] Client %d returned ambiguous error: %T %v", w.name, id, err, err)
+			w.operations.Append(op)
+			w.stoppedAmbiguous.Store(true)
+			return false
 		}
-		w.operations.Append(op)
 	}
+	w.operations.Append(op)
+	return true
+}
+
+// invokeNemesis invokes instr on gen, which returned it for itself, and returns
+// the nemesis event to record. Events always have a return time, even if the
+// generator failed to provide one, so that they are not taken as ambiguous.
+func invokeNemesis(id int, instr gorgon.Instruction, gen gorgon.Generator, getTime func() int64) gorgon.Operation {
+	event := gorgon.Operation{ClientId: id, Input: instr, Call: getTime(), Nemesis: true}
+	event.Return, event.Output = gen.Invoke(instr, getTime)
+	if event.Return < event.Call {
+		event.Return = getTime()
+	}
+	return event
 }
 
 func (w *worker) getNext(id int) (gorgon.Instruction, gorgon.Generator, error) {
 	w.genMutex.Lock()
 	defer w.genMutex.Unlock()
+	done := 0
 	for i := len(w.generators) - 1; i >= 0; i-- {
 		gen := w.generators[i]
 		instr, err := gen.Next(id)
+		if err == gorgon.ErrGeneratorDone {
+			done++
+			continue
+		}
 		if err != nil {
 			log.Error("[%s] Generator %q failed: %v", w.name, gen.Name(), err)
 			return nil, nil, err
@@ -262,6 +560,9 @@ func (w *worker) getNext(id int) (gorgon.Instruction, gorgon.Generator, error) {
 			return instr, gen, nil
 		}
 	}
+	if done == len(w.generators) {
+		return nil, nil, gorgon.ErrGeneratorDone
+	}
 	return nil, nil, nil
 }
 
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// A schedule spaces out the arrivals of the instructions of an open-loop
+// client.
+type schedule interface {
+	// interval returns the time between the arrival at elapsed since the
+	// start of the workload and the next one.
+	interval(elapsed time.Duration) time.Duration
+}
+
+// schedules maps the names accepted by -gorgon-schedule to constructors
+// given the rate of a single client in instructions per second.
+var schedules = map[string]func(rate float64, duration time.Duration, r *rand.Rand) schedule{
+	"constant": func(rate float64, duration time.Duration, r *rand.Rand) schedule {
+		return constantSchedule{rate: rate}
+	},
+	"poisson": func(rate float64, duration time.Duration, r *rand.Rand) schedule {
+		return poissonSchedule{rate: rate, rand: r}
+	},
+	"ramp": func(rate float64, duration time.Duration, r *rand.Rand) schedule {
+		return rampSchedule{rate: rate, duration: duration}
+	},
+}
+
+func scheduleNames() []string {
+	names := make([]string, 0, len(schedules))
+	for name := range schedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// secondsToDuration returns an interval of at least 1ns, so that arrivals
+// advance even at rates beyond the resolution of time.Duration.
+func secondsToDuration(seconds float64) time.Duration {
+	if d := time.Duration(seconds * float64(time.Second)); d > 0 {
+		return d
+	}
+	return time.Nanosecond
+}
+
+// constantSchedule issues instructions at fixed intervals.
+type constantSchedule struct {
+	rate float64
+}
+
+func (s constantSchedule) interval(time.Duration) time.Duration {
+	return secondsToDuration(1 / s.rate)
+}
+
+// poissonSchedule issues instructions at exponentially distributed intervals,
+// so arrivals are bursty as in a Poisson process.
+type poissonSchedule struct {
+	rate float64
+	rand *rand.Rand
+}
+
+func (s poissonSchedule) interval(time.Duration) time.Duration {
+	return secondsToDuration(s.rand.ExpFloat64() / s.rate)
+}
+
+// rampSchedule raises the rate linearly from a tenth of rate at the start of
+// the workload to rate at its end.
+type rampSchedule struct {
+	rate     float64
+	duration time.Duration
+}
+
+func (s rampSchedule) interval(elapsed time.Duration) time.Duration {
+	progress := 1.0
+	if elapsed < s.duration {
+		progress = float64(elapsed) / float64(s.duration)
+	}
+	return secondsToDuration(1 / (s.rate * (0.1 + 0.9*progress)))
+}
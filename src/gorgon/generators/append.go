@@ -0,0 +1,78 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// AppendInstruction appends a unique element to the set or list at Key.
+type AppendInstruction struct {
+	Key     string
+	Element int
+}
+
+// ReadSetInstruction reads all elements at Key. Its output is an []int.
+type ReadSetInstruction struct {
+	Key string
+}
+
+func (op *AppendInstruction) GetKey() string {
+	return op.Key
+}
+
+func (op *ReadSetInstruction) GetKey() string {
+	return op.Key
+}
+
+func (op *AppendInstruction) String() string {
+	return fmt.Sprintf("Append(%q, %d)", op.Key, op.Element)
+}
+
+func (op *ReadSetInstruction) String() string {
+	return fmt.Sprintf("ReadSet(%q)", op.Key)
+}
+
+func (op *AppendInstruction) ForSelf() bool {
+	return false
+}
+
+func (op *ReadSetInstruction) ForSelf() bool {
+	return false
+}
+
+// NewAppendGenerator returns a generator of appends of unique elements to
+// keys, mixed with reads.
+func NewAppendGenerator(keys []string) gorgon.Generator {
+	return &appendGenerator{keys: keys}
+}
+
+type appendGenerator struct {
+	Base
+	keys []string
+	rand *rand.Rand
+	val  int
+}
+
+func (gen *appendGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	key := gen.keys[gen.rand.Intn(len(gen.keys))]
+	if gen.rand.Intn(4) == 0 {
+		return &ReadSetInstruction{Key: key}, nil
+	}
+	gen.val++
+	return &AppendInstruction{Key: key, Element: gen.val}, nil
+}
+
+func (gen *appendGenerator) Name() string {
+	return "Append"
+}
+
+func (gen *appendGenerator) SetUp(opt *gorgon.Options) error {
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	return nil
+}
@@ -0,0 +1,119 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// InitAccountsInstruction sets every key in Keys to Balance.
+type InitAccountsInstruction struct {
+	Keys    []string
+	Balance int
+}
+
+// TransferInstruction atomically moves Amount from the account at From to
+// the account at To. Its output is true if the money was moved, and false if
+// From had insufficient funds.
+type TransferInstruction struct {
+	From   string
+	To     string
+	Amount int
+}
+
+// ReadAllInstruction atomically reads the accounts at Keys. Its output is an
+// []int with the balances in the order of Keys.
+type ReadAllInstruction struct {
+	Keys []string
+}
+
+func (op *InitAccountsInstruction) String() string {
+	return fmt.Sprintf("InitAccounts(%q, %d)", op.Keys, op.Balance)
+}
+
+func (op *TransferInstruction) String() string {
+	return fmt.Sprintf("Transfer(%q, %q, %d)", op.From, op.To, op.Amount)
+}
+
+func (op *ReadAllInstruction) String() string {
+	return fmt.Sprintf("ReadAll(%q)", op.Keys)
+}
+
+func (op *InitAccountsInstruction) ForSelf() bool {
+	return false
+}
+
+func (op *TransferInstruction) ForSelf() bool {
+	return false
+}
+
+func (op *ReadAllInstruction) ForSelf() bool {
+	return false
+}
+
+// NewBankGenerator returns a generator of transfers between accounts, mixed
+// with reads of all accounts. The first client to ask initializes every
+// account to balance, and the other clients wait until it succeeds. If the
+// initialization fails, the next client to ask tries again, so that a client
+// stopped by an ambiguous failure does not stall the workload.
+func NewBankGenerator(accounts []string, balance int) gorgon.Generator {
+	return &bankGenerator{accounts: accounts, balance: balance}
+}
+
+type bankGenerator struct {
+	Base
+	accounts    []string
+	balance     int
+	rand        *rand.Rand
+	initPending bool
+	initialized bool
+}
+
+func (gen *bankGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	if !gen.initialized {
+		if gen.initPending {
+			return nil, nil
+		}
+		gen.initPending = true
+		return &InitAccountsInstruction{Keys: gen.accounts, Balance: gen.balance}, nil
+	}
+	if gen.rand.Intn(10) < 3 {
+		return &ReadAllInstruction{Keys: gen.accounts}, nil
+	}
+	from := gen.rand.Intn(len(gen.accounts))
+	to := gen.rand.Intn(len(gen.accounts) - 1)
+	if to >= from {
+		to++
+	}
+	amount := gen.rand.Intn(gen.balance/2) + 1
+	return &TransferInstruction{From: gen.accounts[from], To: gen.accounts[to], Amount: amount}, nil
+}
+
+func (gen *bankGenerator) Name() string {
+	return "Bank"
+}
+
+func (gen *bankGenerator) SetUp(opt *gorgon.Options) error {
+	if len(gen.accounts) < 2 || gen.balance < 2 {
+		return fmt.Errorf("bank generator needs at least 2 accounts and a balance of at least 2")
+	}
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	gen.initPending = false
+	gen.initialized = false
+	return nil
+}
+
+func (gen *bankGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	if _, ok := instruction.(*InitAccountsInstruction); ok {
+		gen.initPending = false
+		if _, failed := output.(error); !failed {
+			gen.initialized = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package generators
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// ErrForeignInstruction is the output of Invoke for a ForSelf instruction that
+// the generator did not produce.
+var ErrForeignInstruction = gorgon.WrapUnambiguousError(errors.New("generators: instruction produced by another generator"))
+
+// Base implements every method of gorgon.Generator except Name and Next as a
+// no-op, so that a generator embedding it only has to supply those two. A
+// generator that produces ForSelf instructions passes them through Own in
+// Next, and its Invoke checks them with Disown before handling them.
+type Base struct {
+	mutex sync.Mutex
+	owned map[gorgon.Instruction]bool
+}
+
+func (*Base) SetUp(opt *gorgon.Options) error {
+	return nil
+}
+
+func (*Base) TearDown() error {
+	return nil
+}
+
+func (*Base) OnCall(client int, instruction gorgon.Instruction) error {
+	return nil
+}
+
+func (*Base) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	return nil
+}
+
+func (base *Base) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	if err := base.Disown(instruction); err != nil {
+		return getTime(), err
+	}
+	return getTime(), gorgon.ErrUnsupportedInstruction
+}
+
+// Own records instruction, if it is ForSelf, as produced by the generator and
+// returns it.
+func (base *Base) Own(instruction gorgon.Instruction) gorgon.Instruction {
+	if instruction == nil || !instruction.ForSelf() {
+		return instruction
+	}
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	if base.owned == nil {
+		base.owned = make(map[gorgon.Instruction]bool)
+	}
+	base.owned[instruction] = true
+	return instruction
+}
+
+// Disown returns ErrForeignInstruction if instruction is ForSelf but was not
+// passed through Own, or was already disowned.
+func (base *Base) Disown(instruction gorgon.Instruction) error {
+	if !instruction.ForSelf() {
+		return nil
+	}
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	if !base.owned[instruction] {
+		return ErrForeignInstruction
+	}
+	delete(base.owned, instruction)
+	return nil
+}
+
+// Funcs are the methods of a generator made by FromFuncs. Name and Next are
+// required, the others default to those of Base.
+type Funcs struct {
+	Name     string
+	Next     func(client int) (gorgon.Instruction, error)
+	SetUp    func(opt *gorgon.Options) error
+	TearDown func() error
+	OnCall   func(client int, instruction gorgon.Instruction) error
+	OnReturn func(client int, instruction gorgon.Instruction, output gorgon.Output) error
+	// Invoke is only given the ForSelf instructions that Next produced.
+	Invoke func(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output)
+}
+
+// Func returns a generator with the given name and Next.
+func Func(name string, next func(client int) (gorgon.Instruction, error)) gorgon.Generator {
+	return FromFuncs(Funcs{Name: name, Next: next})
+}
+
+// FromFuncs returns a generator with the methods in funcs.
+func FromFuncs(funcs Funcs) gorgon.Generator {
+	if len(funcs.Name) == 0 || funcs.Next == nil {
+		panic(errors.New("generators: FromFuncs needs Name and Next"))
+	}
+	return &funcGenerator{funcs: funcs}
+}
+
+type funcGenerator struct {
+	Base
+	funcs Funcs
+}
+
+func (gen *funcGenerator) Name() string {
+	return gen.funcs.Name
+}
+
+func (gen *funcGenerator) Next(client int) (gorgon.Instruction, error) {
+	instr, err := gen.funcs.Next(client)
+	return gen.Own(instr), err
+}
+
+func (gen *funcGenerator) SetUp(opt *gorgon.Options) error {
+	if gen.funcs.SetUp == nil {
+		return nil
+	}
+	return gen.funcs.SetUp(opt)
+}
+
+func (gen *funcGenerator) TearDown() error {
+	if gen.funcs.TearDown == nil {
+		return nil
+	}
+	return gen.funcs.TearDown()
+}
+
+func (gen *funcGenerator) OnCall(client int, instruction gorgon.Instruction) error {
+	if gen.funcs.OnCall == nil {
+		return nil
+	}
+	return gen.funcs.OnCall(client, instruction)
+}
+
+func (gen *funcGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	if gen.funcs.OnReturn == nil {
+		return nil
+	}
+	return gen.funcs.OnReturn(client, instruction, output)
+}
+
+func (gen *funcGenerator) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	if err := gen.Disown(instruction); err != nil {
+		return getTime(), err
+	}
+	if gen.funcs.Invoke == nil {
+		return getTime(), gorgon.ErrUnsupportedInstruction
+	}
+	return gen.funcs.Invoke(instruction, getTime)
+}
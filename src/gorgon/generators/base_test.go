@@ -0,0 +1,48 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+type selfInstruction struct {
+	id int
+}
+
+func (*selfInstruction) String() string {
+	return "Self()"
+}
+
+func (*selfInstruction) ForSelf() bool {
+	return true
+}
+
+func TestFromFuncsInvokesOwnInstructionsOnly(t *testing.T) {
+	invoked := 0
+	gen := FromFuncs(Funcs{
+		Name: "Self",
+		Next: func(client int) (gorgon.Instruction, error) { return &selfInstruction{id: 1}, nil },
+		Invoke: func(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+			invoked++
+			return getTime(), nil
+		},
+	})
+	getTime := func() int64 { return 1 }
+	instr, err := gen.Next(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, output := gen.Invoke(&selfInstruction{id: 2}, getTime); output != ErrForeignInstruction {
+		t.Errorf("expected ErrForeignInstruction for a foreign instruction, got %v", output)
+	}
+	if _, output := gen.Invoke(instr, getTime); output != nil {
+		t.Errorf("expected nil output, got %v", output)
+	}
+	if _, output := gen.Invoke(instr, getTime); output != ErrForeignInstruction {
+		t.Errorf("expected ErrForeignInstruction for an instruction invoked twice, got %v", output)
+	}
+	if invoked != 1 {
+		t.Errorf("expected 1 invocation, got %d", invoked)
+	}
+}
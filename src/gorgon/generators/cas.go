@@ -0,0 +1,76 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// CasInstruction sets Key to Value if its current value is Expected. Its
+// output is true if the value was swapped, and false otherwise.
+type CasInstruction struct {
+	Key      string
+	Expected int
+	Value    int
+}
+
+func (op *CasInstruction) GetKey() string {
+	return op.Key
+}
+
+func (op *CasInstruction) String() string {
+	return fmt.Sprintf("Cas(%q, %d, %d)", op.Key, op.Expected, op.Value)
+}
+
+func (op *CasInstruction) ForSelf() bool {
+	return false
+}
+
+// NewCasGenerator returns a generator of Get, Set and Cas instructions on
+// keys. Most Cas instructions expect the last value issued for their key, so
+// that a good fraction of them succeed.
+func NewCasGenerator(keys []string) gorgon.Generator {
+	return &casGenerator{keys: keys}
+}
+
+type casGenerator struct {
+	Base
+	keys []string
+	rand *rand.Rand
+	val  int
+	last map[string]int
+}
+
+func (gen *casGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	key := gen.keys[gen.rand.Intn(len(gen.keys))]
+	switch n := gen.rand.Intn(10); {
+	case n < 4:
+		return &GetInstruction{Key: key}, nil
+	case n < 6:
+		gen.val++
+		gen.last[key] = gen.val
+		return &SetInstruction{Key: key, Value: gen.val}, nil
+	}
+	expected := gen.last[key]
+	if gen.rand.Intn(4) == 0 {
+		expected -= gen.rand.Intn(3) + 1
+	}
+	gen.val++
+	gen.last[key] = gen.val
+	return &CasInstruction{Key: key, Expected: expected, Value: gen.val}, nil
+}
+
+func (gen *casGenerator) Name() string {
+	return "Cas"
+}
+
+func (gen *casGenerator) SetUp(opt *gorgon.Options) error {
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	gen.last = make(map[string]int)
+	return nil
+}
@@ -0,0 +1,386 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// group holds the children of a combinator. It sets them up with derived
+// seeds, forwards OnCall, OnReturn and TearDown to all of them, and routes
+// Invoke to the child that produced the instruction.
+type group struct {
+	gens   []gorgon.Generator
+	mutex  sync.Mutex // guards owners, as Invoke runs concurrently with Next
+	owners map[gorgon.Instruction]gorgon.Generator
+}
+
+func (g *group) names() string {
+	names := make([]string, len(g.gens))
+	for i, gen := range g.gens {
+		names[i] = gen.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (g *group) SetUp(opt *gorgon.Options) error {
+	g.mutex.Lock()
+	g.owners = make(map[gorgon.Instruction]gorgon.Generator)
+	g.mutex.Unlock()
+	for i, gen := range g.gens {
+		genOpt := *opt
+		genOpt.Seed = splitmix.Derive(opt.Seed, i)
+		if err := gen.SetUp(&genOpt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *group) TearDown() (retErr error) {
+	for _, gen := range g.gens {
+		if err := gen.TearDown(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}
+	return
+}
+
+// next returns the next instruction of the i-th child for client.
+func (g *group) next(i, client int) (gorgon.Instruction, error) {
+	instr, err := g.gens[i].Next(client)
+	g.own(instr, g.gens[i])
+	return instr, err
+}
+
+// own records gen as the producer of instr if it is ForSelf.
+func (g *group) own(instr gorgon.Instruction, gen gorgon.Generator) {
+	if instr == nil || !instr.ForSelf() {
+		return
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.owners[instr] = gen
+}
+
+func (g *group) OnCall(client int, instruction gorgon.Instruction) error {
+	for _, gen := range g.gens {
+		if err := gen.OnCall(client, instruction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *group) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	for _, gen := range g.gens {
+		if err := gen.OnReturn(client, instruction, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *group) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	g.mutex.Lock()
+	gen, ok := g.owners[instruction]
+	delete(g.owners, instruction)
+	g.mutex.Unlock()
+	if !ok {
+		return getTime(), gorgon.ErrUnsupportedInstruction
+	}
+	return gen.Invoke(instruction, getTime)
+}
+
+// Heal returns the next instruction of the children that are Healers.
+func (g *group) Heal() gorgon.Instruction {
+	for _, gen := range g.gens {
+		if healer, ok := gen.(gorgon.Healer); ok {
+			if instr := healer.Heal(); instr != nil {
+				g.own(instr, gen)
+				return instr
+			}
+		}
+	}
+	return nil
+}
+
+// Mix returns a generator that asks one of gens at random for every
+// instruction, in proportion to weights. If the chosen generator has none,
+// the others are asked in turn.
+func Mix(weights []int, gens ...gorgon.Generator) gorgon.Generator {
+	if len(weights) != len(gens) {
+		panic(fmt.Errorf("generators: Mix got %d weights for %d generators", len(weights), len(gens)))
+	}
+	total := 0
+	for _, w := range weights {
+		if w < 0 {
+			panic(fmt.Errorf("generators: negative weight %d", w))
+		}
+		total += w
+	}
+	return &mix{group: group{gens: gens}, weights: weights, total: total}
+}
+
+type mix struct {
+	group
+	weights []int
+	total   int
+	rand    *rand.Rand
+}
+
+func (m *mix) Name() string {
+	return "Mix(" + m.names() + ")"
+}
+
+func (m *mix) SetUp(opt *gorgon.Options) error {
+	m.rand = splitmix.NewRandSeed(opt.Seed)
+	return m.group.SetUp(opt)
+}
+
+func (m *mix) Next(client int) (gorgon.Instruction, error) {
+	first := 0
+	if m.total > 0 {
+		n := m.rand.Intn(m.total)
+		for n >= m.weights[first] {
+			n -= m.weights[first]
+			first++
+		}
+	}
+	done := 0
+	for k := range m.gens {
+		instr, err := m.next((first+k)%len(m.gens), client)
+		if err == gorgon.ErrGeneratorDone {
+			done++
+			continue
+		}
+		if instr != nil || err != nil {
+			return instr, err
+		}
+	}
+	if done == len(m.gens) {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	return nil, nil
+}
+
+// Sequence returns a generator that gives every client the instructions of
+// gens one after another, moving on once a generator is done for the client.
+func Sequence(gens ...gorgon.Generator) gorgon.Generator {
+	return &sequence{group: group{gens: gens}}
+}
+
+type sequence struct {
+	group
+	current map[int]int // client -> index of its current generator
+}
+
+func (seq *sequence) Name() string {
+	return "Sequence(" + seq.names() + ")"
+}
+
+func (seq *sequence) SetUp(opt *gorgon.Options) error {
+	seq.current = make(map[int]int)
+	return seq.group.SetUp(opt)
+}
+
+func (seq *sequence) Next(client int) (gorgon.Instruction, error) {
+	for i := seq.current[client]; i < len(seq.gens); i++ {
+		instr, err := seq.next(i, client)
+		if err == gorgon.ErrGeneratorDone {
+			seq.current[client] = i + 1
+			continue
+		}
+		return instr, err
+	}
+	return nil, gorgon.ErrGeneratorDone
+}
+
+// Limit returns a generator of at most n of the instructions of gen over all
+// clients.
+func Limit(n int, gen gorgon.Generator) gorgon.Generator {
+	return &limit{group: group{gens: []gorgon.Generator{gen}}, n: n}
+}
+
+type limit struct {
+	group
+	n     int
+	count int
+}
+
+func (l *limit) Name() string {
+	return fmt.Sprintf("Limit(%d,%s)", l.n, l.names())
+}
+
+func (l *limit) SetUp(opt *gorgon.Options) error {
+	l.count = 0
+	return l.group.SetUp(opt)
+}
+
+func (l *limit) Next(client int) (gorgon.Instruction, error) {
+	if l.count >= l.n {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	instr, err := l.next(0, client)
+	if instr != nil {
+		l.count++
+	}
+	return instr, err
+}
+
+// During returns a generator of the instructions of gen during the first d of
+// the workload, counted from the first call of Next.
+func During(d time.Duration, gen gorgon.Generator) gorgon.Generator {
+	return &window{group: group{gens: []gorgon.Generator{gen}}, from: 0, until: d}
+}
+
+// After returns a generator of the instructions of gen once d of the workload
+// has passed, counted from the first call of Next.
+func After(d time.Duration, gen gorgon.Generator) gorgon.Generator {
+	return &window{group: group{gens: []gorgon.Generator{gen}}, from: d, until: -1}
+}
+
+type window struct {
+	group
+	from, until time.Duration // since start, until is negative for no end
+	start       time.Time     // of the first call of Next since SetUp
+}
+
+func (w *window) Name() string {
+	if w.until >= 0 {
+		return fmt.Sprintf("During(%s,%s)", w.until, w.names())
+	}
+	return fmt.Sprintf("After(%s,%s)", w.from, w.names())
+}
+
+func (w *window) SetUp(opt *gorgon.Options) error {
+	w.start = time.Time{}
+	return w.group.SetUp(opt)
+}
+
+func (w *window) Next(client int) (gorgon.Instruction, error) {
+	if w.start.IsZero() {
+		w.start = time.Now()
+	}
+	elapsed := time.Since(w.start)
+	if w.until >= 0 && elapsed >= w.until {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	if elapsed < w.from {
+		return nil, nil
+	}
+	return w.next(0, client)
+}
+
+// OnlyClients returns a generator of the instructions of gen for the given
+// clients only, where -1 stands for the nemesis worker.
+func OnlyClients(clients []int, gen gorgon.Generator) gorgon.Generator {
+	set := make(map[int]bool, len(clients))
+	for _, c := range clients {
+		set[c] = true
+	}
+	return &onlyClients{group: group{gens: []gorgon.Generator{gen}}, clients: set}
+}
+
+type onlyClients struct {
+	group
+	clients map[int]bool
+}
+
+func (oc *onlyClients) Name() string {
+	clients := make([]int, 0, len(oc.clients))
+	for c := range oc.clients {
+		clients = append(clients, c)
+	}
+	sort.Ints(clients)
+	return fmt.Sprintf("OnlyClients(%v,%s)", clients, oc.names())
+}
+
+func (oc *onlyClients) Next(client int) (gorgon.Instruction, error) {
+	if !oc.clients[client] {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	return oc.next(0, client)
+}
+
+// Repeat returns a generator that runs gen n times, or forever if n is 0. Gen
+// is set up again, with a new seed, once it is done for every client that
+// asked it for instructions and did not stop on an ambiguous error.
+func Repeat(n int, gen gorgon.Generator) gorgon.Generator {
+	return &repeat{group: group{gens: []gorgon.Generator{gen}}, n: n}
+}
+
+type repeat struct {
+	group
+	n       int
+	round   int
+	opt     gorgon.Options
+	asked   map[int]bool // clients that asked in this round
+	done    map[int]bool // clients for which gen is done in this round
+	stopped map[int]bool // clients that stopped on an ambiguous error
+}
+
+func (r *repeat) Name() string {
+	return fmt.Sprintf("Repeat(%d,%s)", r.n, r.names())
+}
+
+func (r *repeat) SetUp(opt *gorgon.Options) error {
+	r.opt = *opt
+	r.round = 0
+	r.stopped = make(map[int]bool)
+	return r.setUpRound()
+}
+
+func (r *repeat) setUpRound() error {
+	r.asked = make(map[int]bool)
+	r.done = make(map[int]bool)
+	opt := r.opt
+	opt.Seed = splitmix.Derive(r.opt.Seed, r.round)
+	return r.group.SetUp(&opt)
+}
+
+func (r *repeat) Next(client int) (gorgon.Instruction, error) {
+	if r.n > 0 && r.round >= r.n {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	if r.stopped[client] {
+		return nil, nil
+	}
+	if !r.done[client] {
+		r.asked[client] = true
+		instr, err := r.next(0, client)
+		if err != gorgon.ErrGeneratorDone {
+			return instr, err
+		}
+		r.done[client] = true
+	}
+	if len(r.done) < len(r.asked) {
+		return nil, nil
+	}
+	r.round++
+	if r.n > 0 && r.round >= r.n {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	if err := r.group.TearDown(); err != nil {
+		return nil, err
+	}
+	return nil, r.setUpRound()
+}
+
+// OnReturn leaves clients that stop on an ambiguous error out of the round,
+// so that the others do not wait for them.
+func (r *repeat) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	if err, ok := output.(error); ok && client >= 0 && !instruction.ForSelf() &&
+		!gorgon.IsUnambiguousError(err) && !r.opt.ContinueAmbiguousClient {
+		r.stopped[client] = true
+		delete(r.asked, client)
+		delete(r.done, client)
+	}
+	return r.group.OnReturn(client, instruction, output)
+}
@@ -0,0 +1,169 @@
+package generators
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// countGenerator gives every client n Get instructions of its key.
+type countGenerator struct {
+	key    string
+	n      int
+	issued map[int]int
+	setUps int
+}
+
+func (gen *countGenerator) Next(client int) (gorgon.Instruction, error) {
+	if gen.issued[client] >= gen.n {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	gen.issued[client]++
+	return &GetInstruction{Key: gen.key}, nil
+}
+
+func (gen *countGenerator) Name() string {
+	return gen.key
+}
+
+func (gen *countGenerator) SetUp(opt *gorgon.Options) error {
+	gen.issued = make(map[int]int)
+	gen.setUps++
+	return nil
+}
+
+func (gen *countGenerator) TearDown() error {
+	return nil
+}
+
+func (gen *countGenerator) OnCall(client int, instruction gorgon.Instruction) error {
+	return nil
+}
+
+func (gen *countGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	return nil
+}
+
+func (gen *countGenerator) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	return getTime(), gorgon.ErrUnsupportedInstruction
+}
+
+// drain returns the keys of the instructions that gen gives client until it
+// is done or has to wait.
+func drain(t *testing.T, gen gorgon.Generator, client int) (keys []string, done bool) {
+	for i := 0; i < 1000; i++ {
+		instr, err := gen.Next(client)
+		if err == gorgon.ErrGeneratorDone {
+			return keys, true
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if instr == nil {
+			return keys, false
+		}
+		keys = append(keys, instr.(*GetInstruction).Key)
+	}
+	t.Fatal("generator is not done")
+	return nil, false
+}
+
+func setUp(t *testing.T, gen gorgon.Generator) gorgon.Generator {
+	if err := gen.SetUp(&gorgon.Options{Seed: 1}); err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+func TestSequenceAndLimit(t *testing.T) {
+	gen := setUp(t, Sequence(&countGenerator{key: "a", n: 2}, Limit(3, &countGenerator{key: "b", n: 5})))
+	if keys, done := drain(t, gen, 0); !done || len(keys) != 5 || keys[1] != "a" || keys[2] != "b" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	if keys, done := drain(t, gen, 1); !done || len(keys) != 2 || keys[1] != "a" {
+		t.Errorf("unexpected keys %v after limit", keys)
+	}
+	if name := gen.Name(); name != "Sequence(a,Limit(3,b))" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
+
+func TestMix(t *testing.T) {
+	gen := setUp(t, Mix([]int{3, 1}, &countGenerator{key: "a", n: 300}, &countGenerator{key: "b", n: 300}))
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		instr, err := gen.Next(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[instr.(*GetInstruction).Key]++
+	}
+	if counts["a"] < 250 || counts["b"] < 50 {
+		t.Errorf("unexpected mix %v", counts)
+	}
+	if keys, done := drain(t, gen, 0); !done || len(keys) != 200 {
+		t.Errorf("expected the remaining 200 instructions, got %d", len(keys))
+	}
+}
+
+func TestOnlyClientsAndWindows(t *testing.T) {
+	gen := setUp(t, OnlyClients([]int{1}, &countGenerator{key: "a", n: 1}))
+	if keys, done := drain(t, gen, 0); !done || len(keys) != 0 {
+		t.Errorf("expected done for client 0, got %v", keys)
+	}
+	if keys, done := drain(t, gen, 1); !done || len(keys) != 1 {
+		t.Errorf("expected one instruction for client 1, got %v", keys)
+	}
+	if name := gen.Name(); name != "OnlyClients([1],a)" {
+		t.Errorf("unexpected name %q", name)
+	}
+	if keys, done := drain(t, setUp(t, After(time.Hour, &countGenerator{key: "a", n: 1})), 0); done || len(keys) != 0 {
+		t.Errorf("expected to wait, got %v", keys)
+	}
+	if keys, done := drain(t, setUp(t, During(0, &countGenerator{key: "a", n: 1})), 0); !done || len(keys) != 0 {
+		t.Errorf("expected done, got %v", keys)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	child := &countGenerator{key: "a", n: 2}
+	gen := setUp(t, Repeat(3, child))
+	total := 0
+	for i := 0; i < 10; i++ {
+		keys, _ := drain(t, gen, 0)
+		total += len(keys)
+	}
+	if total != 6 || child.setUps != 3 {
+		t.Errorf("expected 6 instructions in 3 rounds, got %d in %d", total, child.setUps)
+	}
+}
+
+func TestRepeatWithoutStoppedClient(t *testing.T) {
+	child := &countGenerator{key: "a", n: 1}
+	gen := setUp(t, Repeat(2, child))
+	instr, err := gen.Next(1)
+	if err != nil || instr == nil {
+		t.Fatalf("unexpected %v, %v for client 1", instr, err)
+	}
+	if err := gen.OnReturn(1, instr, errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for i := 0; i < 10; i++ {
+		keys, _ := drain(t, gen, 0)
+		total += len(keys)
+	}
+	if total != 2 || child.setUps != 2 {
+		t.Errorf("expected 2 instructions in 2 rounds for client 0, got %d in %d", total, child.setUps)
+	}
+}
+
+func TestWindowStartsAtFirstNext(t *testing.T) {
+	gen := setUp(t, After(20*time.Millisecond, &countGenerator{key: "a", n: 1}))
+	time.Sleep(30 * time.Millisecond)
+	if keys, done := drain(t, gen, 0); done || len(keys) != 0 {
+		t.Errorf("expected to wait after the first call, got %v", keys)
+	}
+}
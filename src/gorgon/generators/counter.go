@@ -0,0 +1,102 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// IncrInstruction adds Delta, which may be negative, to the counter at Key. A
+// missing counter is 0.
+type IncrInstruction struct {
+	Key   string
+	Delta int
+}
+
+// ReadCounterInstruction reads the counter at Key. Its output is an int, 0 if
+// the counter is missing.
+type ReadCounterInstruction struct {
+	Key string
+}
+
+func (op *IncrInstruction) GetKey() string {
+	return op.Key
+}
+
+func (op *ReadCounterInstruction) GetKey() string {
+	return op.Key
+}
+
+func (op *IncrInstruction) String() string {
+	return fmt.Sprintf("Incr(%q, %d)", op.Key, op.Delta)
+}
+
+func (op *ReadCounterInstruction) String() string {
+	return fmt.Sprintf("ReadCounter(%q)", op.Key)
+}
+
+func (op *IncrInstruction) ForSelf() bool {
+	return false
+}
+
+func (op *ReadCounterInstruction) ForSelf() bool {
+	return false
+}
+
+// NewCounterGenerator returns a generator of increments, decrements and reads
+// of the counters at keys. A decrement is only issued if the counter cannot
+// drop below 0, even if every pending decrement takes effect, since some
+// databases saturate counters at 0.
+func NewCounterGenerator(keys []string) gorgon.Generator {
+	return &counterGenerator{keys: keys}
+}
+
+type counterGenerator struct {
+	Base
+	keys []string
+	rand *rand.Rand
+	low  map[string]int // lower bound of every counter
+}
+
+func (gen *counterGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	key := gen.keys[gen.rand.Intn(len(gen.keys))]
+	if gen.rand.Intn(3) == 0 {
+		return &ReadCounterInstruction{Key: key}, nil
+	}
+	delta := gen.rand.Intn(5) + 1
+	if gen.rand.Intn(3) == 0 && gen.low[key] >= delta {
+		gen.low[key] -= delta
+		return &IncrInstruction{Key: key, Delta: -delta}, nil
+	}
+	return &IncrInstruction{Key: key, Delta: delta}, nil
+}
+
+func (gen *counterGenerator) Name() string {
+	return "Counter"
+}
+
+func (gen *counterGenerator) SetUp(opt *gorgon.Options) error {
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	gen.low = make(map[string]int)
+	return nil
+}
+
+func (gen *counterGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	instr, ok := instruction.(*IncrInstruction)
+	if !ok {
+		return nil
+	}
+	err, failed := output.(error)
+	switch {
+	case instr.Delta > 0 && !failed:
+		gen.low[instr.Key] += instr.Delta
+	case instr.Delta < 0 && failed && gorgon.IsUnambiguousError(err):
+		gen.low[instr.Key] -= instr.Delta
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package generators
+
+import (
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// NewFinalReadGenerator returns a final generator that makes every client
+// invoke read(key) for each of keys in turn, retrying a failed read a few
+// times before moving on to the next key.
+func NewFinalReadGenerator(keys []string, read func(key string) gorgon.Instruction) gorgon.Generator {
+	return &finalReadGenerator{keys: keys, read: read}
+}
+
+type finalReadGenerator struct {
+	Base
+	keys   []string
+	read   func(key string) gorgon.Instruction
+	issued map[int]gorgon.Instruction
+	next   map[int]int // client -> index of the key it reads
+	failed map[int]int // client -> failed reads of the key
+}
+
+const finalReadAttempts = 5
+
+func (gen *finalReadGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	i := gen.next[client]
+	if i >= len(gen.keys) {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	instr := gen.read(gen.keys[i])
+	gen.issued[client] = instr
+	return instr, nil
+}
+
+func (gen *finalReadGenerator) Name() string {
+	return "FinalRead"
+}
+
+func (gen *finalReadGenerator) SetUp(opt *gorgon.Options) error {
+	gen.issued = make(map[int]gorgon.Instruction)
+	gen.next = make(map[int]int)
+	gen.failed = make(map[int]int)
+	return nil
+}
+
+func (gen *finalReadGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	if gen.issued[client] != instruction {
+		return nil
+	}
+	delete(gen.issued, client)
+	if _, ok := output.(error); ok {
+		gen.failed[client]++
+		if gen.failed[client] < finalReadAttempts {
+			return nil
+		}
+	}
+	gen.failed[client] = 0
+	gen.next[client]++
+	return nil
+}
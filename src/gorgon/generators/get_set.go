@@ -1,8 +1,10 @@
 package generators
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/pavlosg/gorgon/src/gorgon"
 	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
@@ -12,9 +14,12 @@ type GetInstruction struct {
 	Key string
 }
 
+// SetInstruction sets Key to Value. If Size is not 0, the document written is
+// EncodeValue(Value, Size) rather than the plain int.
 type SetInstruction struct {
 	Key   string
 	Value int
+	Size  int `json:",omitempty"`
 }
 
 func (op *GetInstruction) GetKey() string {
@@ -30,6 +35,9 @@ func (op *GetInstruction) String() string {
 }
 
 func (op *SetInstruction) String() string {
+	if op.Size != 0 {
+		return fmt.Sprintf("Set(%q, %d, %dB)", op.Key, op.Value, op.Size)
+	}
 	return fmt.Sprintf("Set(%q, %d)", op.Key, op.Value)
 }
 
@@ -41,26 +49,86 @@ func (op *SetInstruction) ForSelf() bool {
 	return false
 }
 
+type paddedValue struct {
+	Value   int    `json:"value"`
+	Padding string `json:"padding"`
+}
+
+// EncodeValue returns the JSON document of about size bytes that holds value.
+func EncodeValue(value, size int) []byte {
+	doc, _ := json.Marshal(paddedValue{Value: value})
+	if pad := size - len(doc); pad > 0 {
+		doc, _ = json.Marshal(paddedValue{Value: value, Padding: strings.Repeat("x", pad)})
+	}
+	return doc
+}
+
+// DecodeValue returns the value of a JSON document that is either a plain int
+// or written by EncodeValue.
+func DecodeValue(doc []byte) (int, error) {
+	var value int
+	if err := json.Unmarshal(doc, &value); err == nil {
+		return value, nil
+	}
+	var padded paddedValue
+	if err := json.Unmarshal(doc, &padded); err != nil {
+		return 0, err
+	}
+	return padded.Value, nil
+}
+
+// LegacyReadRatio is the GetSetConfig.ReadRatio with which odd-numbered clients
+// only Get and even-numbered clients Get half of the time, as
+// NewGetSetGenerator does.
+const LegacyReadRatio = -1
+
+// GetSetConfig configures the generator of NewGetSetGeneratorWith.
+type GetSetConfig struct {
+	Keys         []string
+	Distribution KeyDistribution // of the keys, Uniform if nil
+	ReadRatio    float64         // fraction of the instructions that are Gets, or LegacyReadRatio
+	ValueSize    int             // size of the documents written, 0 for plain ints
+}
+
+// NewGetSetGenerator returns a generator of Gets and Sets of uniformly chosen
+// keys. Odd-numbered clients only Get, and even-numbered clients Set half of
+// the time.
 func NewGetSetGenerator(keys []string) gorgon.Generator {
-	return &getSetGenerator{keys: keys, rand: splitmix.NewRand()}
+	return NewGetSetGeneratorWith(GetSetConfig{Keys: keys, ReadRatio: LegacyReadRatio})
+}
+
+func NewGetSetGeneratorWith(config GetSetConfig) gorgon.Generator {
+	if config.Distribution == nil {
+		config.Distribution = Uniform()
+	}
+	return &getSetGenerator{config: config}
 }
 
 type getSetGenerator struct {
-	keys []string
-	rand *rand.Rand
-	val  int
+	Base
+	config GetSetConfig
+	rand   *rand.Rand
+	pick   func() int // index of the next key
+	val    int
 }
 
 func (gen *getSetGenerator) Next(client int) (gorgon.Instruction, error) {
 	if client < 0 {
 		return nil, nil
 	}
-	key := gen.keys[gen.rand.Intn(len(gen.keys))]
-	if client&1 != 0 || gen.rand.Int63()&1 != 0 {
+	key := gen.config.Keys[gen.pick()]
+	if gen.read(client) {
 		return &GetInstruction{Key: key}, nil
 	}
 	gen.val++
-	return &SetInstruction{Key: key, Value: gen.val}, nil
+	return &SetInstruction{Key: key, Value: gen.val, Size: gen.config.ValueSize}, nil
+}
+
+func (gen *getSetGenerator) read(client int) bool {
+	if gen.config.ReadRatio == LegacyReadRatio {
+		return client&1 != 0 || gen.rand.Int63()&1 != 0
+	}
+	return gen.rand.Float64() < gen.config.ReadRatio
 }
 
 func (gen *getSetGenerator) Name() string {
@@ -68,21 +136,13 @@ func (gen *getSetGenerator) Name() string {
 }
 
 func (gen *getSetGenerator) SetUp(opt *gorgon.Options) error {
+	if len(gen.config.Keys) == 0 {
+		return fmt.Errorf("GetSet generator needs at least one key")
+	}
+	if r := gen.config.ReadRatio; r != LegacyReadRatio && (r < 0 || r > 1) {
+		return fmt.Errorf("GetSet generator has invalid read ratio %v", gen.config.ReadRatio)
+	}
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	gen.pick = gen.config.Distribution.Picker(gen.rand, len(gen.config.Keys))
 	return nil
 }
-
-func (gen *getSetGenerator) TearDown() error {
-	return nil
-}
-
-func (gen *getSetGenerator) OnCall(client int, instruction gorgon.Instruction) error {
-	return nil
-}
-
-func (gen *getSetGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
-	return nil
-}
-
-func (gen *getSetGenerator) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
-	return getTime(), gorgon.ErrUnsupportedInstruction
-}
@@ -0,0 +1,46 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// getSetCounts returns the Gets and Sets that gen gives clients 0 and 1.
+func getSetCounts(t *testing.T, gen gorgon.Generator) (gets, sets [2]int) {
+	setUp(t, gen)
+	for i := 0; i < 100; i++ {
+		for client := 0; client < 2; client++ {
+			instr, err := gen.Next(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch instr.(type) {
+			case *GetInstruction:
+				gets[client]++
+			case *SetInstruction:
+				sets[client]++
+			default:
+				t.Fatalf("unexpected instruction %v", instr)
+			}
+		}
+	}
+	return
+}
+
+func TestGetSetReadRatio(t *testing.T) {
+	keys := Keys(4)
+	if gets, _ := getSetCounts(t, NewGetSetGeneratorWith(GetSetConfig{Keys: keys, ReadRatio: 0})); gets != [2]int{} {
+		t.Errorf("expected no Gets with read ratio 0, got %v", gets)
+	}
+	if _, sets := getSetCounts(t, NewGetSetGeneratorWith(GetSetConfig{Keys: keys, ReadRatio: 1})); sets != [2]int{} {
+		t.Errorf("expected no Sets with read ratio 1, got %v", sets)
+	}
+	gets, sets := getSetCounts(t, NewGetSetGenerator(keys))
+	if sets[1] != 0 || gets[0] == 0 || sets[0] == 0 {
+		t.Errorf("expected Sets on client 0 only, got %v Gets and %v Sets", gets, sets)
+	}
+	if err := NewGetSetGeneratorWith(GetSetConfig{Keys: keys, ReadRatio: -0.5}).SetUp(&gorgon.Options{}); err == nil {
+		t.Error("expected an error for a negative read ratio")
+	}
+}
@@ -0,0 +1,106 @@
+package generators
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Keys returns n keys named key0, key1 and so on.
+func Keys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+	return keys
+}
+
+// A KeyDistribution picks the index of one of n keys.
+type KeyDistribution interface {
+	// Picker returns a function that picks with r the index of one of n keys.
+	// Any state it needs lives in the function, so that generators sharing
+	// the distribution do not share it.
+	Picker(r *rand.Rand, n int) func() int
+	String() string
+}
+
+// Uniform picks every key with the same probability.
+func Uniform() KeyDistribution {
+	return uniform{}
+}
+
+type uniform struct{}
+
+func (uniform) Picker(r *rand.Rand, n int) func() int {
+	return func() int { return r.Intn(n) }
+}
+
+func (uniform) String() string {
+	return "uniform"
+}
+
+// Zipfian picks the i-th key with probability proportional to 1/(i+1)^s,
+// where s > 1.
+func Zipfian(s float64) KeyDistribution {
+	if !(s > 1) {
+		panic(fmt.Errorf("generators: zipfian exponent %v is not greater than 1", s))
+	}
+	return zipfian{s: s}
+}
+
+type zipfian struct {
+	s float64
+}
+
+func (z zipfian) Picker(r *rand.Rand, n int) func() int {
+	zipf := rand.NewZipf(r, z.s, 1, uint64(n-1))
+	return func() int { return int(zipf.Uint64()) }
+}
+
+func (z zipfian) String() string {
+	return fmt.Sprintf("zipfian(%v)", z.s)
+}
+
+// Hotspot picks one of the first fraction of the keys with the given
+// probability, and one of the rest otherwise, uniformly in both cases.
+func Hotspot(fraction, probability float64) KeyDistribution {
+	if fraction <= 0 || fraction >= 1 || probability < 0 || probability > 1 {
+		panic(fmt.Errorf("generators: invalid hotspot of %v of the keys with probability %v", fraction, probability))
+	}
+	return hotspot{fraction: fraction, probability: probability}
+}
+
+type hotspot struct {
+	fraction, probability float64
+}
+
+func (h hotspot) Picker(r *rand.Rand, n int) func() int {
+	hot := int(math.Ceil(float64(n) * h.fraction))
+	return func() int {
+		if hot >= n {
+			return r.Intn(n)
+		}
+		if r.Float64() < h.probability {
+			return r.Intn(hot)
+		}
+		return hot + r.Intn(n-hot)
+	}
+}
+
+func (h hotspot) String() string {
+	return fmt.Sprintf("hotspot(%v, %v)", h.fraction, h.probability)
+}
+
+// ParseKeyDistribution returns the distribution named uniform, zipfian
+// (exponent 1.1) or hotspot (80% of the picks on 20% of the keys).
+func ParseKeyDistribution(name string) (KeyDistribution, error) {
+	switch name {
+	case "uniform":
+		return Uniform(), nil
+	case "zipfian":
+		return Zipfian(1.1), nil
+	case "hotspot":
+		return Hotspot(0.2, 0.8), nil
+	}
+	return nil, fmt.Errorf("unknown key distribution %q", name)
+}
@@ -0,0 +1,56 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+func TestKeyDistributions(t *testing.T) {
+	const n, picks = 10, 10000
+	for _, test := range []struct {
+		name               string
+		minFirst, maxFirst int // picks of the first two keys
+	}{
+		{"uniform", picks / 10, picks * 3 / 10},
+		{"zipfian", picks / 2, picks},
+		{"hotspot", picks * 7 / 10, picks * 9 / 10},
+	} {
+		dist, err := ParseKeyDistribution(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pick := dist.Picker(splitmix.NewRandSeed(1), n)
+		first := 0
+		for i := 0; i < picks; i++ {
+			k := pick()
+			if k < 0 || k >= n {
+				t.Fatalf("%s picked key %d out of %d", test.name, k, n)
+			}
+			if k < 2 {
+				first++
+			}
+		}
+		if first < test.minFirst || first > test.maxFirst {
+			t.Errorf("%s picked the first two keys %d times out of %d", test.name, first, picks)
+		}
+	}
+	if _, err := ParseKeyDistribution("normal"); err == nil {
+		t.Error("expected an error for an unknown distribution")
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	for _, size := range []int{0, 10, 1 << 20} {
+		doc := EncodeValue(42, size)
+		if len(doc) < size {
+			t.Errorf("document of %d bytes is smaller than %d", len(doc), size)
+		}
+		if value, err := DecodeValue(doc); err != nil || value != 42 {
+			t.Errorf("decoded %d, %v", value, err)
+		}
+	}
+	if value, err := DecodeValue([]byte("7")); err != nil || value != 7 {
+		t.Errorf("decoded %d, %v from a plain int", value, err)
+	}
+}
@@ -0,0 +1,134 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
+)
+
+// EnqueueInstruction adds a unique element to the queue at Queue.
+type EnqueueInstruction struct {
+	Queue   string
+	Element int
+}
+
+// DequeueInstruction removes an element from the queue at Queue. Its output is
+// the int element, or nil if the queue is empty.
+type DequeueInstruction struct {
+	Queue string
+}
+
+func (op *EnqueueInstruction) GetKey() string {
+	return op.Queue
+}
+
+func (op *DequeueInstruction) GetKey() string {
+	return op.Queue
+}
+
+func (op *EnqueueInstruction) String() string {
+	return fmt.Sprintf("Enqueue(%q, %d)", op.Queue, op.Element)
+}
+
+func (op *DequeueInstruction) String() string {
+	return fmt.Sprintf("Dequeue(%q)", op.Queue)
+}
+
+func (op *EnqueueInstruction) ForSelf() bool {
+	return false
+}
+
+func (op *DequeueInstruction) ForSelf() bool {
+	return false
+}
+
+// NewQueueGenerator returns a generator of enqueues of unique elements to
+// queues, mixed with dequeues.
+func NewQueueGenerator(queues []string) gorgon.Generator {
+	return &queueGenerator{queues: queues}
+}
+
+type queueGenerator struct {
+	Base
+	queues []string
+	rand   *rand.Rand
+	val    int
+}
+
+func (gen *queueGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	queue := gen.queues[gen.rand.Intn(len(gen.queues))]
+	if gen.rand.Intn(2) == 0 {
+		return &DequeueInstruction{Queue: queue}, nil
+	}
+	gen.val++
+	return &EnqueueInstruction{Queue: queue, Element: gen.val}, nil
+}
+
+func (gen *queueGenerator) Name() string {
+	return "Queue"
+}
+
+func (gen *queueGenerator) SetUp(opt *gorgon.Options) error {
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
+	return nil
+}
+
+// NewQueueDrainGenerator returns a final generator that makes every client
+// dequeue from each of queues in turn until it finds it empty, moving on to
+// the next queue after a few failed dequeues in a row.
+func NewQueueDrainGenerator(queues []string) gorgon.Generator {
+	return &queueDrainGenerator{queues: queues}
+}
+
+type queueDrainGenerator struct {
+	Base
+	queues []string
+	next   map[int]int // client -> index of the queue it drains
+	failed map[int]int // client -> failed dequeues in a row
+}
+
+func (gen *queueDrainGenerator) Next(client int) (gorgon.Instruction, error) {
+	if client < 0 {
+		return nil, nil
+	}
+	i := gen.next[client]
+	if i >= len(gen.queues) {
+		return nil, gorgon.ErrGeneratorDone
+	}
+	return &DequeueInstruction{Queue: gen.queues[i]}, nil
+}
+
+func (gen *queueDrainGenerator) Name() string {
+	return "QueueDrain"
+}
+
+func (gen *queueDrainGenerator) SetUp(opt *gorgon.Options) error {
+	gen.next = make(map[int]int)
+	gen.failed = make(map[int]int)
+	return nil
+}
+
+func (gen *queueDrainGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
+	if _, ok := instruction.(*DequeueInstruction); !ok {
+		return nil
+	}
+	_, failed := output.(error)
+	switch {
+	case failed:
+		gen.failed[client]++
+		if gen.failed[client] < finalReadAttempts {
+			return nil
+		}
+	case output != nil: // an element, the queue may have more
+		gen.failed[client] = 0
+		return nil
+	}
+	gen.failed[client] = 0
+	gen.next[client]++
+	return nil
+}
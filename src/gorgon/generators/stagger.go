@@ -8,8 +8,12 @@ import (
 	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
 )
 
+// Stagger returns a generator that spaces out the instructions of gen by pace
+// on average over all clients. In open-loop mode the schedule of the runner
+// spaces out the instructions of the clients instead, so only those of the
+// nemesis worker are staggered.
 func Stagger(gen gorgon.Generator, pace time.Duration) gorgon.Generator {
-	return &stagger{gen, pace, time.Now(), splitmix.NewRand()}
+	return &stagger{gen: gen, pace: pace, next: time.Now()}
 }
 
 type stagger struct {
@@ -17,9 +21,13 @@ type stagger struct {
 	pace time.Duration
 	next time.Time
 	rand *rand.Rand
+	open bool // open-loop mode
 }
 
 func (st *stagger) Next(client int) (gorgon.Instruction, error) {
+	if st.open && client >= 0 {
+		return st.gen.Next(client)
+	}
 	now := time.Now()
 	if now.Before(st.next) {
 		return nil, nil
@@ -41,7 +49,11 @@ func (st *stagger) Name() string {
 }
 
 func (st *stagger) SetUp(opt *gorgon.Options) error {
-	return st.gen.SetUp(opt)
+	st.rand = splitmix.NewRandSeed(opt.Seed)
+	st.open = opt.Rate > 0
+	genOpt := *opt
+	genOpt.Seed = splitmix.Derive(opt.Seed, 0)
+	return st.gen.SetUp(&genOpt)
 }
 
 func (st *stagger) TearDown() error {
@@ -59,3 +71,11 @@ func (st *stagger) OnCall(client int, instruction gorgon.Instruction) error {
 func (st *stagger) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
 	return st.gen.OnReturn(client, instruction, output)
 }
+
+// Heal forwards to gen if it is a Healer.
+func (st *stagger) Heal() gorgon.Instruction {
+	if healer, ok := st.gen.(gorgon.Healer); ok {
+		return healer.Heal()
+	}
+	return nil
+}
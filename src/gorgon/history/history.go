@@ -0,0 +1,144 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
+)
+
+// Header is the first line of a history file.
+type Header struct {
+	Runner string
+	Time   time.Time
+	Seed   int64
+}
+
+// Record is one operation of a history file. Instruction and Value are the
+// registered instruction type and its JSON encoding; OutputType and Output
+// are encoded as by rpcs.EncodeOutput.
+type Record struct {
+	ClientId    int
+	Instruction string
+	Value       json.RawMessage
+	Call        int64
+	Return      int64
+	OutputType  string
+	Output      string
+	Unambiguous bool
+	Nemesis     bool `json:",omitempty"`
+}
+
+var errMissingHeader = errors.New("history: missing header")
+
+func Write(w io.Writer, header Header, history []gorgon.Operation) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(&header); err != nil {
+		return err
+	}
+	for i := range history {
+		rec, err := NewRecord(&history[i])
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WriteFile(path string, header Header, history []gorgon.Operation) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(file)
+	err = Write(buf, header, history)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func Read(r io.Reader) (header Header, history []gorgon.Operation, err error) {
+	dec := json.NewDecoder(r)
+	if err = dec.Decode(&header); err != nil {
+		if err == io.EOF {
+			err = errMissingHeader
+		}
+		return
+	}
+	for line := 2; ; line++ {
+		var rec Record
+		if err = dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				err = nil
+			} else {
+				err = fmt.Errorf("history: record %d: %v", line, err)
+			}
+			return
+		}
+		var op gorgon.Operation
+		if op, err = rec.Operation(); err != nil {
+			err = fmt.Errorf("history: record %d: %v", line, err)
+			return
+		}
+		history = append(history, op)
+	}
+}
+
+func ReadFile(path string) (Header, []gorgon.Operation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer file.Close()
+	return Read(bufio.NewReader(file))
+}
+
+func NewRecord(op *gorgon.Operation) (*Record, error) {
+	value, err := json.Marshal(op.Input)
+	if err != nil {
+		return nil, err
+	}
+	outputType, output, err := rpcs.EncodeOutput(op.Output)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		ClientId:    op.ClientId,
+		Instruction: rpcs.InstructionName(op.Input),
+		Value:       value,
+		Call:        op.Call,
+		Return:      op.Return,
+		OutputType:  outputType,
+		Output:      output,
+		Unambiguous: outputType == "unambiguous_error",
+		Nemesis:     op.Nemesis,
+	}, nil
+}
+
+func (rec *Record) Operation() (gorgon.Operation, error) {
+	instr, err := rpcs.NewInstruction(rec.Instruction, rec.Value)
+	if err != nil {
+		return gorgon.Operation{}, err
+	}
+	return gorgon.Operation{
+		ClientId: rec.ClientId,
+		Input:    instr,
+		Call:     rec.Call,
+		Output:   rpcs.DecodeOutput(rec.OutputType, rec.Output),
+		Return:   rec.Return,
+		Nemesis:  rec.Nemesis,
+	}, nil
+}
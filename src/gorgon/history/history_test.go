@@ -0,0 +1,65 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rpcs.RegisterInstruction(&generators.GetInstruction{})
+	rpcs.RegisterInstruction(&generators.SetInstruction{})
+
+	ops := []gorgon.Operation{
+		{ClientId: 0, Input: &generators.SetInstruction{Key: "key0", Value: 1}, Call: 1, Return: 2},
+		{ClientId: 1, Input: &generators.GetInstruction{Key: "key0"}, Call: 3, Output: 1, Return: 4},
+		{ClientId: 2, Input: &generators.GetInstruction{Key: "key1"}, Call: 5, Return: 6},
+		{ClientId: 0, Input: &generators.SetInstruction{Key: "key1", Value: 2}, Call: 7,
+			Output: gorgon.WrapUnambiguousError(errors.New("timeout")), Return: 8},
+		{ClientId: 1, Input: &generators.SetInstruction{Key: "key1", Value: 3}, Call: 9,
+			Output: errors.New("ambiguous"), Return: 10},
+		{ClientId: -1, Input: &generators.GetInstruction{Key: "key1"}, Call: 11, Return: 12, Nemesis: true},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, Header{Runner: "test~GetSet"}, ops); err != nil {
+		t.Fatal(err)
+	}
+	header, loaded, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Runner != "test~GetSet" {
+		t.Errorf("unexpected runner %q", header.Runner)
+	}
+	if len(loaded) != len(ops) {
+		t.Fatalf("expected %d operations, got %d", len(ops), len(loaded))
+	}
+	for i, op := range ops {
+		got := loaded[i]
+		if got.ClientId != op.ClientId || got.Call != op.Call || got.Return != op.Return || got.Nemesis != op.Nemesis {
+			t.Errorf("operation %d: expected %+v, got %+v", i, op, got)
+		}
+		if got.Input.String() != op.Input.String() {
+			t.Errorf("operation %d: expected input %s, got %s", i, op.Input, got.Input)
+		}
+		if err, ok := op.Output.(error); ok {
+			gotErr, ok := got.Output.(error)
+			if !ok || gotErr.Error() != err.Error() ||
+				gorgon.IsUnambiguousError(gotErr) != gorgon.IsUnambiguousError(err) {
+				t.Errorf("operation %d: expected output %v, got %v", i, op.Output, got.Output)
+			}
+		} else if got.Output != op.Output {
+			t.Errorf("operation %d: expected output %v, got %v", i, op.Output, got.Output)
+		}
+	}
+}
+
+func TestMissingHeader(t *testing.T) {
+	if _, _, err := Read(&bytes.Buffer{}); err != errMissingHeader {
+		t.Errorf("expected %v, got %v", errMissingHeader, err)
+	}
+}
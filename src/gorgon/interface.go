@@ -1,17 +1,25 @@
 package gorgon
 
 import (
+	"context"
 	"errors"
 	"time"
 )
 
 var ErrUnsupportedInstruction = WrapUnambiguousError(errors.New("gorgon: unsupported instruction"))
 
+// ErrGeneratorDone is returned by Generator.Next when the generator has no
+// more instructions for the client.
+var ErrGeneratorDone = errors.New("gorgon: generator done")
+
 type Instruction interface {
 	String() string
 	ForSelf() bool
 }
 
+// A Client invokes instructions on the database. In open-loop mode (Options.Rate
+// greater than 0) Invoke is called concurrently, with up to Options.InFlight
+// instructions in flight per client.
 type Client interface {
 	Id() int
 	Open(config string) error
@@ -19,6 +27,11 @@ type Client interface {
 	Close() error
 }
 
+// A Generator produces the instructions of the clients and of the nemesis
+// worker, which is client -1. In open-loop mode (Options.Rate greater than 0)
+// Next is called for a client that may still have instructions in flight, so
+// a generator that assumes one instruction in flight per client should fail
+// SetUp in that mode.
 type Generator interface {
 	Name() string
 	SetUp(opt *Options) error
@@ -32,6 +45,14 @@ type Generator interface {
 type Workload struct {
 	Model
 	Generators []Generator
+	// Checkers run in addition to the linearizability check of Model, which
+	// is skipped if Model.Step is nil.
+	Checkers []Checker
+	// Final generators run after the workload duration, once the nemeses
+	// healed and the quiesce period passed, on every client, with fresh
+	// clients in place of those that stopped. They run until every one of
+	// them returns ErrGeneratorDone, or for at most another workload duration.
+	Final []Generator
 }
 
 func (w Workload) Add(generator Generator) Workload {
@@ -39,6 +60,42 @@ func (w Workload) Add(generator Generator) Workload {
 	return w
 }
 
+func (w Workload) AddChecker(checker Checker) Workload {
+	w.Checkers = append(w.Checkers, checker)
+	return w
+}
+
+// A Healer is a Generator that injects faults, such as a nemesis. Once the
+// workload duration passed, Heal is called until it returns nil, and every
+// instruction it returns is passed to the generator's Invoke to undo faults.
+type Healer interface {
+	Heal() Instruction
+}
+
+type Checker interface {
+	Name() string
+	// Check returns the results of the partitions of history that are
+	// checked independently. Partitions that are still being checked when ctx
+	// is cancelled are unknown.
+	Check(ctx context.Context, history []Operation, opt *CheckOptions) ([]PartitionResult, error)
+}
+
+type CheckOptions struct {
+	Timeout time.Duration // per partition, 0 for none
+	Workers int           // number of partitions to check in parallel
+	// ArtifactPath returns the path of a file that a checker may write, such
+	// as a visualization, given a suffix unique to the checker and partition.
+	ArtifactPath func(suffix string) string
+}
+
+type PartitionResult struct {
+	Index         int
+	Operations    int
+	Result        CheckResult
+	Visualization string `json:",omitempty"` // empty if no visualization was written
+	Details       string `json:",omitempty"` // anomalies found, if any
+}
+
 type Database interface {
 	Name() string
 	SetOptions(opt *Options) error
@@ -57,6 +114,17 @@ type Options struct {
 	ContinueAmbiguousClient bool
 	RpcPort                 int
 	RpcPassword             string
+	Seed                    int64 // master seed, or the generator's own seed in Generator.SetUp
+	CheckTimeout            time.Duration
+	CheckWorkers            int
+	OutputDir               string
+	Repeat                  int
+	SoakDuration            time.Duration
+	StopOnViolation         bool
+	QuiesceDuration         time.Duration
+	Rate                    float64 // instructions per second across all clients, 0 for closed-loop
+	Schedule                string  // arrivals of the open-loop instructions: constant, poisson or ramp
+	InFlight                int     // maximum open-loop instructions in flight per client
 }
 
 type Operation struct {
@@ -65,8 +133,19 @@ type Operation struct {
 	Call     int64 // invocation timestamp
 	Output   Output
 	Return   int64 // response timestamp
+	// Nemesis is set for instructions that a generator invoked on itself,
+	// such as faults, rather than through a client. Checkers skip them.
+	Nemesis bool
 }
 
+type CheckResult string
+
+const (
+	CheckOk      CheckResult = "ok"
+	CheckIllegal CheckResult = "illegal"
+	CheckUnknown CheckResult = "unknown" // the checker timed out or was cancelled
+)
+
 type State = any
 
 type Output = any
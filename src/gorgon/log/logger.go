@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	std_log "log"
 	"runtime"
 	"strconv"
@@ -21,6 +22,26 @@ var _logger Logger = func(level Level, message string) {
 	std_log.Println("gorgon:", level, message)
 }
 
+// NewWriterLogger returns a Logger that writes to w in the format of the
+// default Logger.
+func NewWriterLogger(w io.Writer) Logger {
+	logger := std_log.New(w, "", std_log.Ldate|std_log.Ltime|std_log.Lmicroseconds)
+	return func(level Level, message string) {
+		logger.Println("gorgon:", level, message)
+	}
+}
+
+// Tee returns a Logger that forwards every message to all loggers.
+func Tee(loggers ...Logger) Logger {
+	return func(level Level, message string) {
+		for _, logger := range loggers {
+			if logger != nil {
+				logger(level, message)
+			}
+		}
+	}
+}
+
 func GetLogger() Logger {
 	return _logger
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
 	"github.com/pavlosg/gorgon/src/gorgon/jrpc"
 	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
 	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
@@ -16,6 +17,7 @@ func NewKillNemesis(process string) gorgon.Generator {
 }
 
 type killNemesis struct {
+	generators.Base
 	process string
 	next    time.Time
 	client  *rpc.Client
@@ -25,24 +27,16 @@ func (nemesis *killNemesis) Name() string {
 	return fmt.Sprintf("Kill(%s)", nemesis.process)
 }
 
-func (*killNemesis) OnCall(client int, instruction gorgon.Instruction) error {
-	return nil
-}
-
-func (*killNemesis) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
-	return nil
-}
-
 func (nemesis *killNemesis) Next(client int) (gorgon.Instruction, error) {
 	if client >= 0 || time.Until(nemesis.next) > 0 {
 		return nil, nil
 	}
 	nemesis.next = nemesis.next.Add(8 * time.Second)
-	return &rpcs.KillInstruction{Process: nemesis.process, Signal: 9}, nil
+	return nemesis.Own(&rpcs.KillInstruction{Process: nemesis.process, Signal: 9}), nil
 }
 
 func (nemesis *killNemesis) SetUp(opt *gorgon.Options) error {
-	node := opt.Nodes[splitmix.Rand.Intn(len(opt.Nodes))]
+	node := opt.Nodes[splitmix.NewRandSeed(opt.Seed).Intn(len(opt.Nodes))]
 	client, err := jrpc.Dial(fmt.Sprintf("%s:%d", node, opt.RpcPort), []byte(opt.RpcPassword))
 	if err != nil {
 		return err
@@ -60,6 +54,9 @@ func (nemesis *killNemesis) TearDown() error {
 }
 
 func (nemesis *killNemesis) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	if err := nemesis.Disown(instruction); err != nil {
+		return -1, err
+	}
 	if instr, ok := instruction.(*rpcs.KillInstruction); ok {
 		var reply string
 		err := nemesis.client.Call("KillRpc.Pkill", instr, &reply)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
 	"github.com/pavlosg/gorgon/src/gorgon/jrpc"
 	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
 )
@@ -32,6 +33,7 @@ func NewNetworkPartitionNemesis(allowedPorts ...int) gorgon.Generator {
 }
 
 type networkPartition struct {
+	generators.Base
 	allowedPorts  []int
 	client        *rpc.Client
 	node          string
@@ -46,14 +48,6 @@ func (*networkPartition) Name() string {
 	return "NetworkPartition"
 }
 
-func (*networkPartition) OnCall(client int, instruction gorgon.Instruction) error {
-	return nil
-}
-
-func (*networkPartition) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
-	return nil
-}
-
 func (nemesis *networkPartition) Next(client int) (gorgon.Instruction, error) {
 	if client >= 0 {
 		return nil, nil
@@ -63,21 +57,33 @@ func (nemesis *networkPartition) Next(client int) (gorgon.Instruction, error) {
 			return nil, nil
 		}
 		nemesis.partitioned = true
-		return &PartitionNodeInstruction{Node: nemesis.nodeIdx, Heal: false}, nil
+		return nemesis.Own(&PartitionNodeInstruction{Node: nemesis.nodeIdx, Heal: false}), nil
 	}
 	if !nemesis.healed {
 		if time.Until(nemesis.healTime) > 0 {
 			return nil, nil
 		}
 		nemesis.healed = true
-		return &PartitionNodeInstruction{Node: nemesis.nodeIdx, Heal: true}, nil
+		return nemesis.Own(&PartitionNodeInstruction{Node: nemesis.nodeIdx, Heal: true}), nil
 	}
 	return nil, nil
 }
 
+// Heal returns the instruction that heals the partition if it was not healed
+// yet.
+func (nemesis *networkPartition) Heal() gorgon.Instruction {
+	if !nemesis.partitioned || nemesis.healed {
+		return nil
+	}
+	nemesis.healed = true
+	return nemesis.Own(&PartitionNodeInstruction{Node: nemesis.nodeIdx, Heal: true})
+}
+
 func (nemesis *networkPartition) SetUp(opt *gorgon.Options) error {
 	now := time.Now()
-	nemesis.nodeIdx = splitmix.Rand.Intn(len(opt.Nodes))
+	nemesis.partitioned = false
+	nemesis.healed = false
+	nemesis.nodeIdx = splitmix.NewRandSeed(opt.Seed).Intn(len(opt.Nodes))
 	nemesis.node = opt.Nodes[nemesis.nodeIdx]
 	nemesis.partitionTime = now.Add(opt.WorkloadDuration / 4)
 	nemesis.healTime = now.Add(opt.WorkloadDuration * 3 / 4)
@@ -110,6 +116,9 @@ func (nemesis *networkPartition) TearDown() error {
 }
 
 func (nemesis *networkPartition) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
+	if err := nemesis.Disown(instruction); err != nil {
+		return -1, err
+	}
 	heal := false
 	if instr, ok := instruction.(*PartitionNodeInstruction); ok {
 		if instr.Node != nemesis.nodeIdx {
@@ -0,0 +1,24 @@
+package gorgon
+
+// ClientOperations returns the operations of history that were invoked
+// through clients, skipping nemesis events.
+func ClientOperations(history []Operation) []Operation {
+	ret := make([]Operation, 0, len(history))
+	for _, op := range history {
+		if !op.Nemesis {
+			ret = append(ret, op)
+		}
+	}
+	return ret
+}
+
+// NemesisOperations returns the nemesis events of history.
+func NemesisOperations(history []Operation) []Operation {
+	var ret []Operation
+	for _, op := range history {
+		if op.Nemesis {
+			ret = append(ret, op)
+		}
+	}
+	return ret
+}
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/rpc"
-	"reflect"
-	"strconv"
 	"sync"
 
 	"github.com/pavlosg/gorgon/src/gorgon"
@@ -14,14 +12,6 @@ import (
 	"github.com/pavlosg/gorgon/src/gorgon/log"
 )
 
-func RegisterInstruction(instrunction gorgon.Instruction) {
-	rtype := reflect.TypeOf(instrunction)
-	for rtype.Kind() == reflect.Pointer {
-		rtype = rtype.Elem()
-	}
-	instructions[rtype.PkgPath()+"."+rtype.Name()] = rtype
-}
-
 func NewClientOverRpc(id int, node string, opt *gorgon.Options) gorgon.Client {
 	return &clientOverRpc{id: id, node: node, opt: opt}
 }
@@ -36,9 +26,11 @@ type ClientRpc struct {
 	mutex   sync.Mutex
 }
 
+// lockableClient is invoked concurrently, as in open-loop mode, and closed
+// once no invocation is in progress.
 type lockableClient struct {
 	client gorgon.Client
-	mutex  sync.Mutex
+	mutex  sync.RWMutex
 }
 
 func (rpc *ClientRpc) OpenClient(arg *RpcOpenClient, reply *string) error {
@@ -77,38 +69,14 @@ func (rpc *ClientRpc) CloseClient(id *int, reply *string) error {
 }
 
 func (rpc *ClientRpc) Invoke(arg *RpcInvoke, reply *RpcInvokeReply) error {
-	var instruction gorgon.Instruction
-	if rtype, ok := instructions[arg.Instructon]; ok {
-		instr := reflect.New(rtype).Interface()
-		if err := json.Unmarshal([]byte(arg.Value), instr); err != nil {
-			return fmt.Errorf("ClientRpc.Invoke: error unmarshalling instruction %s: %v", arg.Instructon, err)
-		}
-		instruction = instr.(gorgon.Instruction)
-	} else {
-		return fmt.Errorf("ClientRpc.Invoke: unknown instruction type %s", arg.Instructon)
+	instruction, err := NewInstruction(arg.Instructon, []byte(arg.Value))
+	if err != nil {
+		return fmt.Errorf("ClientRpc.Invoke: %v", err)
 	}
 	output := rpc.invoke(arg.Id, instruction)
-	if output == nil {
-		reply.Type = "nil"
-		reply.Value = "null"
-		return nil
-	}
-	switch v := output.(type) {
-	case int:
-		reply.Type = "int"
-		reply.Value = strconv.Itoa(v)
-	case string:
-		reply.Type = "string"
-		reply.Value = v
-	case error:
-		if gorgon.IsUnambiguousError(v) {
-			reply.Type = "unambiguous_error"
-		} else {
-			reply.Type = "error"
-		}
-		reply.Value = v.Error()
-	default:
-		return fmt.Errorf("ClientRpc.Invoke: unexpected output type %T", output)
+	reply.Type, reply.Value, err = EncodeOutput(output)
+	if err != nil {
+		return fmt.Errorf("ClientRpc.Invoke: %v", err)
 	}
 	return nil
 }
@@ -123,9 +91,9 @@ func (rpc *ClientRpc) invoke(id int, instruction gorgon.Instruction) gorgon.Outp
 		rpc.mutex.Unlock()
 		return errors.New("ClientRpc: client not found")
 	}
-	client.mutex.Lock()
+	client.mutex.RLock()
 	_, output := client.client.Invoke(instruction, func() int64 { return 0 })
-	client.mutex.Unlock()
+	client.mutex.RUnlock()
 	return output
 }
 
@@ -169,35 +137,13 @@ func (c *clientOverRpc) Invoke(instruction gorgon.Instruction, getTime func() in
 		return getTime(), errors.New("ClientOverRpc: failed to marshal instruction")
 	}
 	var reply RpcInvokeReply
-	rtype := reflect.TypeOf(instruction)
-	for rtype.Kind() == reflect.Pointer {
-		rtype = rtype.Elem()
-	}
-	arg := RpcInvoke{Id: c.id, Instructon: rtype.PkgPath() + "." + rtype.Name(), Value: string(instructionJson)}
+	arg := RpcInvoke{Id: c.id, Instructon: InstructionName(instruction), Value: string(instructionJson)}
 	err = c.client.Call("ClientRpc.Invoke", &arg, &reply)
 	retTime = getTime()
 	if err != nil {
 		return retTime, err
 	}
-	switch reply.Type {
-	case "nil":
-		output = nil
-	case "int":
-		i, err := strconv.Atoi(reply.Value)
-		if err != nil {
-			output = fmt.Errorf("ClientOverRpc.Invoke: expected int, got %s", reply.Value)
-		} else {
-			output = i
-		}
-	case "string":
-		output = reply.Value
-	case "unambiguous_error":
-		output = gorgon.WrapUnambiguousError(errors.New(reply.Value))
-	case "error":
-		output = errors.New(reply.Value)
-	default:
-		output = fmt.Errorf("ClientOverRpc.Invoke: unexpected reply type %s", reply.Type)
-	}
+	output = DecodeOutput(reply.Type, reply.Value)
 	return
 }
 
@@ -216,5 +162,3 @@ type RpcInvokeReply struct {
 	Type  string
 	Value string
 }
-
-var instructions = make(map[string]reflect.Type)
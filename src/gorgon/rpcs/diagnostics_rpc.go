@@ -0,0 +1,219 @@
+package rpcs
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon/log"
+)
+
+// diagnosticsChunkSize is the most bytes of an archive that Fetch returns.
+const diagnosticsChunkSize = 4 << 20
+
+// DiagnosticsRpc collects the log directories and the output of diagnostic
+// commands of the node it runs on. Collect archives them into a file on the
+// node, which is then fetched in chunks, as it can be large.
+type DiagnosticsRpc struct {
+	logDirs  []string
+	commands [][]string
+	mutex    sync.Mutex
+	archives map[int]string // id -> path of the archives not fetched yet
+	nextId   int
+}
+
+// NewDiagnosticsRpc returns a service that archives logDirs and runs
+// commands, each given as a program followed by its arguments. Commands run
+// in a scratch directory, and the files they write there are archived too.
+func NewDiagnosticsRpc(logDirs []string, commands [][]string) *DiagnosticsRpc {
+	return &DiagnosticsRpc{logDirs: logDirs, commands: commands, archives: make(map[int]string)}
+}
+
+type DiagnosticsArgs struct {
+	Timeout time.Duration // per command, 0 for none
+}
+
+type DiagnosticsReply struct {
+	Archive int      // id of the gzipped tar of logs/ and commands/ to Fetch
+	Size    int64    // of the archive in bytes
+	Errors  []string // of the logs and commands that could not be collected
+}
+
+type DiagnosticsFetchArgs struct {
+	Archive int
+	Offset  int64
+}
+
+type DiagnosticsChunk struct {
+	Data []byte // at Offset, empty once the whole archive was fetched
+}
+
+func (d *DiagnosticsRpc) Collect(arg *DiagnosticsArgs, reply *DiagnosticsReply) error {
+	file, err := os.CreateTemp("", "gorgon-diagnostics-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	buf := bufio.NewWriter(file)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	var errs []string
+	for _, dir := range d.logDirs {
+		name := path.Join("logs", strings.TrimPrefix(filepath.ToSlash(dir), "/"))
+		if err := addDir(tw, dir, name); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", dir, err))
+		}
+	}
+	for i, command := range d.commands {
+		if len(command) == 0 {
+			errs = append(errs, fmt.Sprintf("command %d is empty", i))
+			continue
+		}
+		if err := runDiagnostic(tw, arg.Timeout, command, fmt.Sprintf("commands/%d-%s", i, path.Base(command[0]))); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", strings.Join(command, " "), err))
+		}
+	}
+	err = tw.Close()
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	var info os.FileInfo
+	if err == nil {
+		info, err = file.Stat()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	log.Info("Collect() archived %d bytes to %s with %d errors", info.Size(), file.Name(), len(errs))
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.nextId++
+	d.archives[d.nextId] = file.Name()
+	reply.Archive = d.nextId
+	reply.Size = info.Size()
+	reply.Errors = errs
+	return nil
+}
+
+// Fetch returns the chunk of an archive of Collect at arg.Offset. The archive
+// is removed from the node once an empty chunk past its end is returned.
+func (d *DiagnosticsRpc) Fetch(arg *DiagnosticsFetchArgs, reply *DiagnosticsChunk) error {
+	d.mutex.Lock()
+	filePath, ok := d.archives[arg.Archive]
+	d.mutex.Unlock()
+	if !ok {
+		return errors.New("DiagnosticsRpc: archive not found")
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data := make([]byte, diagnosticsChunkSize)
+	n, err := file.ReadAt(data, arg.Offset)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n == 0 {
+		d.mutex.Lock()
+		delete(d.archives, arg.Archive)
+		d.mutex.Unlock()
+		return os.Remove(filePath)
+	}
+	reply.Data = data[:n]
+	return nil
+}
+
+// runDiagnostic runs command in a scratch directory and archives its combined
+// output as name.log and the files it wrote under name/.
+func runDiagnostic(tw *tar.Writer, timeout time.Duration, command []string, name string) error {
+	scratch, err := os.MkdirTemp("", "gorgon-diagnostics-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = scratch
+	output, runErr := cmd.CombinedOutput()
+	log.Info("Diagnostic %v returned %v", command, runErr)
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name + ".log",
+		Mode:    0644,
+		Size:    int64(len(output)),
+		ModTime: time.Now(),
+	})
+	if err == nil {
+		_, err = tw.Write(output)
+	}
+	if err == nil {
+		err = addDir(tw, scratch, name)
+	}
+	if err != nil {
+		return err
+	}
+	return runErr
+}
+
+// addDir archives the regular files under dir with their paths relative to
+// dir prefixed by name. Files that change while being archived are truncated
+// or zero-padded to the size they had when the walk reached them.
+func addDir(tw *tar.Writer, dir, name string) error {
+	return filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(rel))
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		n, err := io.CopyN(tw, file, header.Size)
+		if n < header.Size {
+			if _, padErr := tw.Write(make([]byte, header.Size-n)); padErr != nil {
+				return padErr
+			}
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package rpcs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsCollect(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "debug.log"), []byte("log line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDiagnosticsRpc([]string{dir, filepath.Join(dir, "missing")}, [][]string{
+		{"sh", "-c", "echo hello; echo data > collected.txt"},
+		{"sh", "-c", "exit 3"},
+		{},
+	})
+	var reply DiagnosticsReply
+	if err := d.Collect(&DiagnosticsArgs{}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Errors) != 3 {
+		t.Errorf("expected errors for the missing directory, the failing command and the empty one, got %q",
+			reply.Errors)
+	}
+
+	var archive bytes.Buffer
+	for {
+		var chunk DiagnosticsChunk
+		err := d.Fetch(&DiagnosticsFetchArgs{Archive: reply.Archive, Offset: int64(archive.Len())}, &chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk.Data) == 0 {
+			break
+		}
+		archive.Write(chunk.Data)
+	}
+	if int64(archive.Len()) != reply.Size {
+		t.Errorf("fetched %d bytes of %d", archive.Len(), reply.Size)
+	}
+	if err := d.Fetch(&DiagnosticsFetchArgs{Archive: reply.Archive}, &DiagnosticsChunk{}); err == nil {
+		t.Error("expected the fetched archive to be removed")
+	}
+
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(content)
+	}
+	logName := "logs/" + strings.TrimPrefix(filepath.ToSlash(dir), "/") + "/sub/debug.log"
+	for name, want := range map[string]string{
+		logName:                       "log line\n",
+		"commands/0-sh.log":           "hello\n",
+		"commands/0-sh/collected.txt": "data\n",
+		"commands/1-sh.log":           "",
+	} {
+		if got, ok := files[name]; !ok || got != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+}
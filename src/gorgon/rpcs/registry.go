@@ -0,0 +1,106 @@
+package rpcs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+func RegisterInstruction(instrunction gorgon.Instruction) {
+	rtype := instructionType(instrunction)
+	instructions[rtype.PkgPath()+"."+rtype.Name()] = rtype
+}
+
+// InstructionName returns the name under which the type of instruction is
+// registered by RegisterInstruction.
+func InstructionName(instruction gorgon.Instruction) string {
+	rtype := instructionType(instruction)
+	return rtype.PkgPath() + "." + rtype.Name()
+}
+
+// NewInstruction unmarshals value into a new instruction of the registered
+// type name.
+func NewInstruction(name string, value []byte) (gorgon.Instruction, error) {
+	rtype, ok := instructions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction type %s", name)
+	}
+	instr := reflect.New(rtype).Interface()
+	if err := json.Unmarshal(value, instr); err != nil {
+		return nil, fmt.Errorf("error unmarshalling instruction %s: %v", name, err)
+	}
+	return instr.(gorgon.Instruction), nil
+}
+
+// EncodeOutput returns the type and string value of output, as sent by
+// ClientRpc.Invoke.
+func EncodeOutput(output gorgon.Output) (string, string, error) {
+	if output == nil {
+		return "nil", "null", nil
+	}
+	switch v := output.(type) {
+	case int:
+		return "int", strconv.Itoa(v), nil
+	case bool:
+		return "bool", strconv.FormatBool(v), nil
+	case string:
+		return "string", v, nil
+	case []int:
+		value, err := json.Marshal(v)
+		return "ints", string(value), err
+	case error:
+		if gorgon.IsUnambiguousError(v) {
+			return "unambiguous_error", v.Error(), nil
+		}
+		return "error", v.Error(), nil
+	}
+	return "", "", fmt.Errorf("unexpected output type %T", output)
+}
+
+// DecodeOutput is the inverse of EncodeOutput. Values that cannot be decoded
+// are returned as errors.
+func DecodeOutput(typ, value string) gorgon.Output {
+	switch typ {
+	case "nil":
+		return nil
+	case "int":
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("rpcs: expected int, got %s", value)
+		}
+		return i
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("rpcs: expected bool, got %s", value)
+		}
+		return b
+	case "string":
+		return value
+	case "ints":
+		var ints []int
+		if err := json.Unmarshal([]byte(value), &ints); err != nil {
+			return fmt.Errorf("rpcs: expected ints, got %s", value)
+		}
+		return ints
+	case "unambiguous_error":
+		return gorgon.WrapUnambiguousError(errors.New(value))
+	case "error":
+		return errors.New(value)
+	}
+	return fmt.Errorf("rpcs: unexpected output type %s", typ)
+}
+
+func instructionType(instruction gorgon.Instruction) reflect.Type {
+	rtype := reflect.TypeOf(instruction)
+	for rtype.Kind() == reflect.Pointer {
+		rtype = rtype.Elem()
+	}
+	return rtype
+}
+
+var instructions = make(map[string]reflect.Type)
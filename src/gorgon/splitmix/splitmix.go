@@ -18,7 +18,16 @@ func New(seed int64) *SplitMix {
 }
 
 func NewRand() *rand.Rand {
-	return rand.New(New(NewSeed()))
+	return NewRandSeed(NewSeed())
+}
+
+func NewRandSeed(seed int64) *rand.Rand {
+	return rand.New(New(seed))
+}
+
+// Derive returns the seed of the index-th independent stream derived from seed.
+func Derive(seed int64, index int) int64 {
+	return int64(splitmixTransform(splitmixTransform(uint64(seed)) + uint64(index+1)*splitmixIncrement))
 }
 
 type SplitMix struct {
@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+)
+
+// subBucketBits sets the precision of a Histogram: values are recorded with
+// a relative error below 1/2^subBucketBits.
+const subBucketBits = 7
+
+// Histogram counts non-negative values, such as latencies in microseconds, in
+// logarithmic buckets that are each split into linear sub-buckets, as an HDR
+// histogram does. Values below 2^(subBucketBits+1) are recorded exactly.
+type Histogram struct {
+	counts []int64
+	total  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func bucketIndex(value int64) int {
+	v := uint64(value)
+	if v < 2<<subBucketBits {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return shift<<subBucketBits + int(v>>shift)
+}
+
+// bucketHighest returns the highest value recorded in the bucket at index.
+func bucketHighest(index int) int64 {
+	if index < 2<<subBucketBits {
+		return int64(index)
+	}
+	shift := index>>subBucketBits - 1
+	mantissa := int64(index - shift<<subBucketBits)
+	return (mantissa+1)<<shift - 1
+}
+
+// Record adds value to the histogram; negative values are recorded as 0.
+func (h *Histogram) Record(value int64) {
+	if value < 0 {
+		value = 0
+	}
+	i := bucketIndex(value)
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	if h.total == 0 || value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	h.total++
+	h.sum += value
+}
+
+// Merge adds the values recorded in other to h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.total == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]int64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	if h.total == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.total += other.total
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+func (h *Histogram) Min() int64 {
+	return h.min
+}
+
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.total)
+}
+
+// Percentile returns the value below or at which q percent of the recorded
+// values fall, within the precision of the histogram, or 0 if it is empty.
+func (h *Histogram) Percentile(q float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			if v := bucketHighest(i); v < h.max {
+				return v
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
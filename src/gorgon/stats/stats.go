@@ -0,0 +1,228 @@
+// Package stats summarizes the latency and throughput of a history.
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+// Latency summarizes a histogram of the latencies, in microseconds, of the
+// operations that returned. Ambiguous operations have no latency and are only
+// counted.
+type Latency struct {
+	Name      string
+	Count     int64
+	Ambiguous int
+	Min       int64
+	Mean      float64
+	P50       int64
+	P90       int64
+	P99       int64
+	P999      int64
+	Max       int64
+}
+
+// Bucket counts the operations of a time window. Operations are counted in
+// the window in which they returned, or, if ambiguous, in which they were
+// invoked.
+type Bucket struct {
+	Start       int64 // microseconds since the start of the run
+	Ok          int
+	Unambiguous int
+	Ambiguous   int
+	// Nemesis lists the nemesis events that overlap the window, if any.
+	Nemesis []string `json:",omitempty"`
+}
+
+type Stats struct {
+	BucketDuration time.Duration
+	Total          Latency
+	ByType         []Latency // sorted by name
+	ByClient       []Latency // sorted by client id
+	Throughput     []Bucket
+}
+
+type latencies struct {
+	histogram Histogram
+	ambiguous int
+}
+
+// ambiguous reports whether op failed with an error that is not unambiguous.
+// Its return time is meaningless, as OperationList.Extract places it after
+// all others.
+func ambiguous(op *gorgon.Operation) bool {
+	err, ok := op.Output.(error)
+	return ok && !gorgon.IsUnambiguousError(err)
+}
+
+func (l *latencies) add(op *gorgon.Operation) {
+	if ambiguous(op) {
+		l.ambiguous++
+	} else {
+		l.histogram.Record(op.Return - op.Call)
+	}
+}
+
+func (l *latencies) summary(name string) Latency {
+	h := &l.histogram
+	return Latency{
+		Name:      name,
+		Count:     h.Count(),
+		Ambiguous: l.ambiguous,
+		Min:       h.Min(),
+		Mean:      h.Mean(),
+		P50:       h.Percentile(50),
+		P90:       h.Percentile(90),
+		P99:       h.Percentile(99),
+		P999:      h.Percentile(99.9),
+		Max:       h.Max(),
+	}
+}
+
+// Compute builds the latency histograms of the client operations of history in
+// total, per instruction type and per client, and counts them in windows of
+// bucket, marking the windows that overlap the nemesis events of history.
+func Compute(history []gorgon.Operation, bucket time.Duration) *Stats {
+	width := bucket.Microseconds()
+	if width <= 0 {
+		panic(fmt.Errorf("stats: invalid bucket duration %v", bucket))
+	}
+	var total latencies
+	byType := make(map[string]*latencies)
+	byClient := make(map[int]*latencies)
+	var buckets []Bucket
+	at := func(t int64) *Bucket {
+		i := int(t / width)
+		if i < 0 {
+			i = 0
+		}
+		for len(buckets) <= i {
+			buckets = append(buckets, Bucket{Start: int64(len(buckets)) * width})
+		}
+		return &buckets[i]
+	}
+	events := gorgon.NemesisOperations(history)
+	history = gorgon.ClientOperations(history)
+	for i := range history {
+		op := &history[i]
+		name := InstructionTypeName(op.Input)
+		if byType[name] == nil {
+			byType[name] = &latencies{}
+		}
+		if byClient[op.ClientId] == nil {
+			byClient[op.ClientId] = &latencies{}
+		}
+		total.add(op)
+		byType[name].add(op)
+		byClient[op.ClientId].add(op)
+		if ambiguous(op) {
+			at(op.Call).Ambiguous++
+		} else if _, ok := op.Output.(error); ok {
+			at(op.Return).Unambiguous++
+		} else {
+			at(op.Return).Ok++
+		}
+	}
+	for i := range events {
+		event := &events[i]
+		end := event.Return
+		if end < event.Call {
+			end = event.Call
+		}
+		at(end)
+		for j := int(event.Call / width); j <= int(end/width); j++ {
+			if j >= 0 {
+				buckets[j].Nemesis = append(buckets[j].Nemesis, event.Input.String())
+			}
+		}
+	}
+
+	stats := &Stats{BucketDuration: bucket, Total: total.summary("Total"), Throughput: buckets}
+	for name, l := range byType {
+		stats.ByType = append(stats.ByType, l.summary(name))
+	}
+	sort.Slice(stats.ByType, func(i, j int) bool { return stats.ByType[i].Name < stats.ByType[j].Name })
+	clients := make([]int, 0, len(byClient))
+	for id := range byClient {
+		clients = append(clients, id)
+	}
+	sort.Ints(clients)
+	for _, id := range clients {
+		stats.ByClient = append(stats.ByClient, byClient[id].summary(fmt.Sprintf("Client %d", id)))
+	}
+	return stats
+}
+
+// InstructionTypeName returns the name of the type of instr without the
+// pointer prefix, such as generators.GetInstruction.
+func InstructionTypeName(instr gorgon.Instruction) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", instr), "*")
+}
+
+// Lines formats the latencies in total and per instruction type as text, one
+// line per histogram.
+func (stats *Stats) Lines() []string {
+	var lines []string
+	for _, l := range append([]Latency{stats.Total}, stats.ByType...) {
+		lines = append(lines, fmt.Sprintf(
+			"%s: %d ops, %d ambiguous, latency us min %d mean %.0f p50 %d p90 %d p99 %d p99.9 %d max %d",
+			l.Name, l.Count, l.Ambiguous, l.Min, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max))
+	}
+	return lines
+}
+
+func (stats *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+// WriteLatencyCSV writes one row per latency histogram: the total, then per
+// instruction type, then per client.
+func (stats *Stats) WriteLatencyCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"name", "count", "ambiguous", "min_us", "mean_us", "p50_us", "p90_us", "p99_us", "p999_us", "max_us"})
+	rows := append([]Latency{stats.Total}, stats.ByType...)
+	for _, l := range append(rows, stats.ByClient...) {
+		out.Write([]string{
+			l.Name,
+			strconv.FormatInt(l.Count, 10),
+			strconv.Itoa(l.Ambiguous),
+			strconv.FormatInt(l.Min, 10),
+			strconv.FormatFloat(l.Mean, 'f', 1, 64),
+			strconv.FormatInt(l.P50, 10),
+			strconv.FormatInt(l.P90, 10),
+			strconv.FormatInt(l.P99, 10),
+			strconv.FormatInt(l.P999, 10),
+			strconv.FormatInt(l.Max, 10),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteThroughputCSV writes one row per time window with the operations
+// counted in it and the nemesis events overlapping it.
+func (stats *Stats) WriteThroughputCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"start_us", "ok", "unambiguous", "ambiguous", "nemesis"})
+	for _, b := range stats.Throughput {
+		out.Write([]string{
+			strconv.FormatInt(b.Start, 10),
+			strconv.Itoa(b.Ok),
+			strconv.Itoa(b.Unambiguous),
+			strconv.Itoa(b.Ambiguous),
+			strings.Join(b.Nemesis, "; "),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h Histogram
+	for v := int64(1); v <= 100000; v++ {
+		h.Record(v)
+	}
+	if h.Count() != 100000 || h.Min() != 1 || h.Max() != 100000 {
+		t.Fatalf("count %d, min %d, max %d", h.Count(), h.Min(), h.Max())
+	}
+	for _, test := range []struct {
+		q    float64
+		want int64
+	}{{0, 1}, {50, 50000}, {99, 99000}, {99.9, 99900}, {100, 100000}} {
+		got := h.Percentile(test.q)
+		if got < test.want || float64(got-test.want) > float64(test.want)/(1<<subBucketBits) {
+			t.Errorf("p%v = %d, want %d within the precision", test.q, got, test.want)
+		}
+	}
+	var merged Histogram
+	merged.Record(1 << 40)
+	merged.Merge(&h)
+	if merged.Count() != 100001 || merged.Min() != 1 || merged.Max() != 1<<40 {
+		t.Errorf("merged count %d, min %d, max %d", merged.Count(), merged.Min(), merged.Max())
+	}
+}
+
+func TestBucketIndexRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, 255, 256, 257, 1000, 123456789, 1 << 50} {
+		high := bucketHighest(bucketIndex(v))
+		if high < v || bucketIndex(high) != bucketIndex(v) || bucketIndex(high+1) == bucketIndex(v) {
+			t.Errorf("value %d in bucket %d with highest value %d", v, bucketIndex(v), high)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	get := &generators.GetInstruction{Key: "key0"}
+	set := &generators.SetInstruction{Key: "key0", Value: 1}
+	history := []gorgon.Operation{
+		{ClientId: 0, Input: set, Call: 0, Return: 100},
+		{ClientId: 1, Input: get, Call: 500_000, Return: 1_200_000, Output: 1},
+		{ClientId: 1, Input: get, Call: 1_300_000, Return: 1_300_300, Output: gorgon.WrapUnambiguousError(errors.New("no"))},
+		{ClientId: -1, Input: get, Call: 1_900_000, Return: 2_100_000, Nemesis: true},
+		{ClientId: 0, Input: set, Call: 2_100_000, Return: 2_100_001, Output: errors.New("timeout")},
+	}
+	st := Compute(history, time.Second)
+	if st.Total.Count != 3 || st.Total.Ambiguous != 1 || st.Total.Max != 700_000 {
+		t.Errorf("total %+v", st.Total)
+	}
+	if len(st.ByType) != 2 || st.ByType[0].Name != "generators.GetInstruction" || st.ByType[0].Count != 2 {
+		t.Errorf("by type %+v", st.ByType)
+	}
+	if len(st.ByClient) != 2 || st.ByClient[1].Name != "Client 1" || st.ByClient[0].Ambiguous != 1 {
+		t.Errorf("by client %+v", st.ByClient)
+	}
+	want := []Bucket{
+		{Start: 0, Ok: 1},
+		{Start: 1_000_000, Ok: 1, Unambiguous: 1, Nemesis: []string{get.String()}},
+		{Start: 2_000_000, Ambiguous: 1, Nemesis: []string{get.String()}},
+	}
+	if len(st.Throughput) != len(want) {
+		t.Fatalf("throughput %+v", st.Throughput)
+	}
+	for i, b := range st.Throughput {
+		if b.Start != want[i].Start || b.Ok != want[i].Ok || b.Unambiguous != want[i].Unambiguous ||
+			b.Ambiguous != want[i].Ambiguous || len(b.Nemesis) != len(want[i].Nemesis) {
+			t.Errorf("bucket %d = %+v, want %+v", i, b, want[i])
+		}
+	}
+}
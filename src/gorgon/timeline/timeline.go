@@ -0,0 +1,207 @@
+// Package timeline draws the operations of a run as a standalone HTML page.
+package timeline
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+const (
+	pixelsPerSecond = 100
+	marginLeft      = 90
+	marginTop       = 30
+	laneHeight      = 18
+	laneGap         = 4
+	minWidth        = 1.0 // of an operation, in pixels
+	minBandWidth    = 3.0 // of a nemesis band, in pixels
+)
+
+type tick struct {
+	X     float64
+	Label string
+}
+
+type lane struct {
+	Y     int
+	Label string
+}
+
+type rect struct {
+	X, Y, Width float64
+	Class       string
+	Title       string
+}
+
+type page struct {
+	Title         string
+	Width, Height int
+	LaneHeight    int
+	BandHeight    int
+	Ticks         []tick
+	Lanes         []lane
+	Bands         []rect
+	Operations    []rect
+}
+
+// Write writes an HTML page to w with one lane per client of history, in which
+// every operation is a bar coloured by its outcome, and a band across all lanes
+// for every nemesis event. Ambiguous operations extend to the end of the run.
+func Write(w io.Writer, title string, history []gorgon.Operation) error {
+	end := int64(0)
+	for i := range history {
+		if history[i].Call > end {
+			end = history[i].Call
+		}
+		if history[i].Return > end {
+			end = history[i].Return
+		}
+	}
+	events := gorgon.NemesisOperations(history)
+	history = gorgon.ClientOperations(history)
+	x := func(t int64) float64 {
+		return marginLeft + float64(t)*pixelsPerSecond/1e6
+	}
+	width := func(call, ret int64, min float64) float64 {
+		if w := x(ret) - x(call); w > min {
+			return w
+		}
+		return min
+	}
+
+	clients := make(map[int]int) // client id -> lane
+	for i := range history {
+		clients[history[i].ClientId] = 0
+	}
+	ids := make([]int, 0, len(clients))
+	for id := range clients {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	laneY := func(lane int) int {
+		return marginTop + lane*(laneHeight+laneGap)
+	}
+	p := &page{Title: title, LaneHeight: laneHeight}
+	p.Lanes = append(p.Lanes, lane{Y: laneY(0), Label: "nemesis"})
+	for i, id := range ids {
+		clients[id] = i + 1
+		p.Lanes = append(p.Lanes, lane{Y: laneY(i + 1), Label: fmt.Sprintf("client %d", id)})
+	}
+	p.Width = int(x(end)) + marginLeft
+	p.Height = laneY(len(ids)+1) + laneGap
+	p.BandHeight = p.Height - marginTop
+	for s := int64(0); s <= end/1e6; s++ {
+		p.Ticks = append(p.Ticks, tick{X: x(s * 1e6), Label: fmt.Sprintf("%ds", s)})
+	}
+
+	for i := range events {
+		event := &events[i]
+		ret := event.Return
+		if ret < event.Call {
+			ret = event.Call
+		}
+		label := fmt.Sprintf("%s at %s", event.Input, formatTime(event.Call))
+		if event.Output != nil {
+			label += fmt.Sprintf(": %v", event.Output)
+		}
+		p.Bands = append(p.Bands, rect{
+			X:     x(event.Call),
+			Y:     float64(laneY(0)),
+			Width: width(event.Call, ret, minBandWidth),
+			Class: "nemesis",
+			Title: label,
+		})
+	}
+	for i := range history {
+		op := &history[i]
+		class, ret := "ok", op.Return
+		if err, ok := op.Output.(error); ok {
+			class = "failed"
+			if !gorgon.IsUnambiguousError(err) {
+				class, ret = "ambiguous", end
+			}
+		}
+		label := fmt.Sprintf("client %d: %s at %s", op.ClientId, op.Input, formatTime(op.Call))
+		if class == "ambiguous" {
+			label += fmt.Sprintf(", ambiguous: %v", op.Output)
+		} else {
+			label += fmt.Sprintf(" for %s: %v", formatTime(op.Return-op.Call), op.Output)
+		}
+		p.Operations = append(p.Operations, rect{
+			X:     x(op.Call),
+			Y:     float64(laneY(clients[op.ClientId])),
+			Width: width(op.Call, ret, minWidth),
+			Class: class,
+			Title: label,
+		})
+	}
+	return pageTemplate.Execute(w, p)
+}
+
+// WriteFile writes the timeline into a new file at path.
+func WriteFile(path string, title string, history []gorgon.Operation) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, title, history); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatTime(us int64) string {
+	if us < 1000 {
+		return fmt.Sprintf("%dus", us)
+	}
+	if us < 1e6 {
+		return fmt.Sprintf("%.1fms", float64(us)/1e3)
+	}
+	return fmt.Sprintf("%.3fs", float64(us)/1e6)
+}
+
+var pageTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 8px; }
+.legend span { display: inline-block; padding: 2px 8px; margin-right: 6px; }
+.ok { fill: #4caf50; background: #4caf50; }
+.failed { fill: #ff9800; background: #ff9800; }
+.ambiguous { fill: #e53935; background: #e53935; fill-opacity: 0.35; }
+.nemesis { fill: #7e57c2; background: #7e57c2; }
+rect.band { fill: #7e57c2; fill-opacity: 0.15; }
+.scroll { overflow-x: auto; }
+text { font-size: 11px; }
+</style>
+</head>
+<body>
+<h3>{{.Title}}</h3>
+<div class="legend"><span class="ok">ok</span><span class="failed">failed</span><span class="ambiguous">ambiguous</span><span class="nemesis">nemesis</span></div>
+<div class="scroll">
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}">
+{{- range .Ticks}}
+<line x1="{{.X}}" y1="20" x2="{{.X}}" y2="{{$.Height}}" stroke="#ddd"/><text x="{{.X}}" y="14" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+{{- range .Bands}}
+<rect class="band" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{$.BandHeight}}"><title>{{.Title}}</title></rect>
+<rect class="{{.Class}}" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{$.LaneHeight}}"><title>{{.Title}}</title></rect>
+{{- end}}
+{{- range .Lanes}}
+<text x="4" y="{{.Y}}" dy="13">{{.Label}}</text>
+{{- end}}
+{{- range .Operations}}
+<rect class="{{.Class}}" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{$.LaneHeight}}"><title>{{.Title}}</title></rect>
+{{- end}}
+</svg>
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,36 @@
+package timeline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+func TestWrite(t *testing.T) {
+	get := &generators.GetInstruction{Key: "<key>"}
+	history := []gorgon.Operation{
+		{ClientId: 0, Input: get, Call: 0, Return: 1000, Output: 1},
+		{ClientId: 2, Input: get, Call: 500, Return: 2000, Output: gorgon.WrapUnambiguousError(errors.New("failed"))},
+		{ClientId: -1, Input: get, Call: 1500, Return: 2500, Nemesis: true},
+		{ClientId: 2, Input: get, Call: 3000, Return: 3001, Output: errors.New("timeout")},
+	}
+	var sb strings.Builder
+	if err := Write(&sb, "run", history); err != nil {
+		t.Fatal(err)
+	}
+	html := sb.String()
+	for _, want := range []string{
+		`<rect class="ok"`, `<rect class="failed"`, `<rect class="ambiguous"`, `<rect class="nemesis"`,
+		"client 0", "client 2", "&lt;key&gt;",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("timeline does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "client 1<") || strings.Contains(html, "client -1") {
+		t.Error("timeline has a lane for a client without operations")
+	}
+}
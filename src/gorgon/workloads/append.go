@@ -0,0 +1,169 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// AppendWorkload appends unique elements to per-key sets and checks the
+// reads with SetChecker, which takes the final reads as the sets' contents.
+// The history is not checked for linearizability, so that it can be
+// arbitrarily long.
+func AppendWorkload() gorgon.Workload {
+	keys := generators.Keys(8)
+	return gorgon.Workload{
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewAppendGenerator(keys), time.Millisecond)},
+		Checkers:   []gorgon.Checker{SetChecker()},
+		Final: []gorgon.Generator{generators.NewFinalReadGenerator(keys, func(key string) gorgon.Instruction {
+			return &generators.ReadSetInstruction{Key: key}
+		})},
+	}
+}
+
+// SetChecker returns a Checker of histories of AppendInstruction and
+// ReadSetInstruction. For every key, it reports acknowledged appends that are
+// missing from the last read, elements that a read returned more than once,
+// and elements that were never attempted or whose append failed unambiguously.
+func SetChecker() gorgon.Checker {
+	return setChecker{}
+}
+
+type setChecker struct{}
+
+func (setChecker) Name() string {
+	return "Set"
+}
+
+func (setChecker) Check(ctx context.Context, history []gorgon.Operation,
+	opt *gorgon.CheckOptions) ([]gorgon.PartitionResult, error) {
+	var results []gorgon.PartitionResult
+	for i, part := range PartitionByKey(history) {
+		result := checkSet(part)
+		result.Index = i
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func checkSet(history []gorgon.Operation) gorgon.PartitionResult {
+	attempted := make(map[int]bool)
+	failed := make(map[int]bool)
+	acked := make(map[int]int64)
+	var reads []gorgon.Operation
+	for _, op := range history {
+		switch instr := op.Input.(type) {
+		case *generators.AppendInstruction:
+			attempted[instr.Element] = true
+			if err, ok := op.Output.(error); ok {
+				if gorgon.IsUnambiguousError(err) {
+					failed[instr.Element] = true
+				}
+			} else {
+				acked[instr.Element] = op.Return
+			}
+		case *generators.ReadSetInstruction:
+			if _, ok := op.Output.([]int); ok {
+				reads = append(reads, op)
+			}
+		}
+	}
+	key := ""
+	if len(history) != 0 {
+		key = history[0].Input.(interface{ GetKey() string }).GetKey()
+	}
+	result := gorgon.PartitionResult{Operations: len(history), Result: gorgon.CheckOk}
+	if len(reads) == 0 {
+		if len(acked) != 0 {
+			result.Result = gorgon.CheckUnknown
+			result.Details = fmt.Sprintf("%q: no successful read", key)
+		}
+		return result
+	}
+	sort.Slice(reads, func(i, j int) bool { return reads[i].Call < reads[j].Call })
+	final := reads[len(reads)-1]
+
+	var duplicated, unexpected, failedPresent, lost elementSet
+	observed := make(map[int]int64) // element -> earliest return of a read that saw it
+	for _, read := range reads {
+		seen := make(map[int]bool)
+		for _, e := range read.Output.([]int) {
+			if seen[e] {
+				duplicated.add(e)
+				continue
+			}
+			seen[e] = true
+			if !attempted[e] {
+				unexpected.add(e)
+			} else if failed[e] {
+				failedPresent.add(e)
+			}
+			if t, ok := observed[e]; !ok || read.Return < t {
+				observed[e] = read.Return
+			}
+		}
+	}
+	inFinal := make(map[int]bool)
+	for _, e := range final.Output.([]int) {
+		inFinal[e] = true
+	}
+	for e, t := range acked {
+		if t < final.Call && !inFinal[e] {
+			lost.add(e)
+		}
+	}
+	for e, t := range observed {
+		if t < final.Call && !inFinal[e] {
+			lost.add(e)
+		}
+	}
+
+	var details []string
+	for _, anomaly := range []struct {
+		name     string
+		elements *elementSet
+	}{
+		{"lost", &lost},
+		{"duplicated", &duplicated},
+		{"unexpected", &unexpected},
+		{"failed but present", &failedPresent},
+	} {
+		if len(anomaly.elements.list) != 0 {
+			details = append(details, fmt.Sprintf("%s %s", anomaly.name, anomaly.elements))
+		}
+	}
+	if len(details) != 0 {
+		result.Result = gorgon.CheckIllegal
+		result.Details = fmt.Sprintf("%q: %s", key, strings.Join(details, ", "))
+	}
+	return result
+}
+
+type elementSet struct {
+	list []int
+	set  map[int]bool
+}
+
+func (s *elementSet) add(e int) {
+	if s.set == nil {
+		s.set = make(map[int]bool)
+	}
+	if !s.set[e] {
+		s.set[e] = true
+		s.list = append(s.list, e)
+	}
+}
+
+func (s *elementSet) String() string {
+	const maxElements = 10
+	sort.Ints(s.list)
+	if len(s.list) > maxElements {
+		return fmt.Sprintf("%v… (%d)", s.list[:maxElements], len(s.list))
+	}
+	return fmt.Sprintf("%v (%d)", s.list, len(s.list))
+}
@@ -0,0 +1,56 @@
+package workloads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+func appendTo(element int) gorgon.Instruction {
+	return &generators.AppendInstruction{Key: "k", Element: element}
+}
+
+var readSet = &generators.ReadSetInstruction{Key: "k"}
+
+func TestSetCheckerOk(t *testing.T) {
+	result := checkSet([]gorgon.Operation{
+		op(appendTo(1), 1, 2, nil),
+		op(appendTo(2), 3, 10, errors.New("timeout")),
+		op(appendTo(3), 4, 5, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(appendTo(4), 6, 20, nil),
+		op(readSet, 7, 8, []int{1}),
+		op(readSet, 11, 12, []int{1, 2}),
+	})
+	if result.Result != gorgon.CheckOk {
+		t.Errorf("expected ok, got %s: %s", result.Result, result.Details)
+	}
+}
+
+func TestSetCheckerAnomalies(t *testing.T) {
+	result := checkSet([]gorgon.Operation{
+		op(appendTo(1), 1, 2, nil),
+		op(appendTo(2), 1, 2, nil),
+		op(appendTo(3), 1, 2, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(appendTo(4), 1, 2, errors.New("timeout")),
+		op(readSet, 3, 4, []int{1, 2, 4}),
+		op(readSet, 5, 6, []int{1, 1, 3, 9}),
+	})
+	if result.Result != gorgon.CheckIllegal {
+		t.Fatalf("expected illegal, got %s", result.Result)
+	}
+	for _, expected := range []string{"lost [2 4] (2)", "duplicated [1] (1)", "unexpected [9] (1)", "failed but present [3] (1)"} {
+		if !strings.Contains(result.Details, expected) {
+			t.Errorf("expected %q in %q", expected, result.Details)
+		}
+	}
+}
+
+func TestSetCheckerNoRead(t *testing.T) {
+	result := checkSet([]gorgon.Operation{op(appendTo(1), 1, 2, nil)})
+	if result.Result != gorgon.CheckUnknown {
+		t.Errorf("expected unknown, got %s", result.Result)
+	}
+}
@@ -0,0 +1,107 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// BankWorkload moves money between five accounts of 100 in transactions and
+// reads all balances at once. What matters is that no money is created or
+// destroyed, which BankChecker verifies on every read.
+func BankWorkload() gorgon.Workload {
+	accounts := []string{"account0", "account1", "account2", "account3", "account4"}
+	return gorgon.Workload{
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewBankGenerator(accounts, 100), time.Millisecond)},
+		Checkers:   []gorgon.Checker{BankChecker()},
+		Final: []gorgon.Generator{generators.NewFinalReadGenerator([]string{"accounts"}, func(string) gorgon.Instruction {
+			return &generators.ReadAllInstruction{Keys: accounts}
+		})},
+	}
+}
+
+// BankChecker returns a Checker of histories of InitAccountsInstruction,
+// TransferInstruction and ReadAllInstruction. It reports every successful
+// read after the accounts were initialized whose balances do not add up to
+// the initial total, or that has a negative balance.
+func BankChecker() gorgon.Checker {
+	return bankChecker{}
+}
+
+type bankChecker struct{}
+
+func (bankChecker) Name() string {
+	return "Bank"
+}
+
+func (bankChecker) Check(ctx context.Context, history []gorgon.Operation,
+	opt *gorgon.CheckOptions) ([]gorgon.PartitionResult, error) {
+	return []gorgon.PartitionResult{checkBank(history)}, nil
+}
+
+func checkBank(history []gorgon.Operation) gorgon.PartitionResult {
+	result := gorgon.PartitionResult{Operations: len(history), Result: gorgon.CheckOk}
+	var init *generators.InitAccountsInstruction
+	var initReturn int64
+	for _, op := range history {
+		if instr, ok := op.Input.(*generators.InitAccountsInstruction); ok {
+			if _, failed := op.Output.(error); !failed {
+				init, initReturn = instr, op.Return
+				break
+			}
+		}
+	}
+	if init == nil {
+		if len(history) != 0 {
+			result.Result = gorgon.CheckUnknown
+			result.Details = "accounts were never initialized"
+		}
+		return result
+	}
+	total := init.Balance * len(init.Keys)
+
+	const maxDetails = 5
+	var details []string
+	bad := 0
+	for _, op := range history {
+		if _, ok := op.Input.(*generators.ReadAllInstruction); !ok || op.Call < initReturn {
+			continue
+		}
+		balances, ok := op.Output.([]int)
+		if !ok {
+			continue
+		}
+		var problems []string
+		if len(balances) != len(init.Keys) {
+			problems = append(problems, fmt.Sprintf("%d accounts", len(balances)))
+		}
+		sum := 0
+		for _, b := range balances {
+			sum += b
+			if b < 0 {
+				problems = append(problems, fmt.Sprintf("negative balance %d", b))
+			}
+		}
+		if sum != total {
+			problems = append(problems, fmt.Sprintf("total %d", sum))
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		bad++
+		if len(details) < maxDetails {
+			details = append(details, fmt.Sprintf("client %d read %v: %s",
+				op.ClientId, balances, strings.Join(problems, ", ")))
+		}
+	}
+	if bad != 0 {
+		result.Result = gorgon.CheckIllegal
+		result.Details = fmt.Sprintf("%d of the reads do not add up to %d; %s",
+			bad, total, strings.Join(details, "; "))
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package workloads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+var bankAccounts = []string{"a", "b", "c"}
+
+var readAll = &generators.ReadAllInstruction{Keys: bankAccounts}
+
+// bankHistory initializes the accounts with 10 each, moves 5 from a to b and
+// maybe 7 from b to c, and then appends reads.
+func bankHistory(reads ...gorgon.Operation) []gorgon.Operation {
+	history := []gorgon.Operation{
+		op(&generators.InitAccountsInstruction{Keys: bankAccounts, Balance: 10}, 1, 2, nil),
+		op(&generators.TransferInstruction{From: "a", To: "b", Amount: 5}, 3, 4, true),
+		op(&generators.TransferInstruction{From: "b", To: "c", Amount: 7}, 3, -1, errors.New("timeout")),
+	}
+	return append(history, reads...)
+}
+
+func TestBankCheckerOk(t *testing.T) {
+	result := checkBank(bankHistory(
+		op(readAll, 0, 1, []int{0, 0, 0}), // before the accounts were initialized
+		op(readAll, 5, 6, []int{5, 15, 10}),
+		op(readAll, 7, 8, []int{5, 8, 17}),
+		op(readAll, 9, 10, errors.New("timeout")),
+	))
+	if result.Result != gorgon.CheckOk {
+		t.Errorf("expected ok, got %s: %s", result.Result, result.Details)
+	}
+}
+
+func TestBankCheckerAnomalies(t *testing.T) {
+	result := checkBank(bankHistory(
+		op(readAll, 5, 6, []int{5, 10, 10}),
+		op(readAll, 7, 8, []int{-2, 17, 15}),
+	))
+	if result.Result != gorgon.CheckIllegal {
+		t.Fatalf("expected illegal, got %s", result.Result)
+	}
+	for _, expected := range []string{"2 of the reads", "total 25", "negative balance -2"} {
+		if !strings.Contains(result.Details, expected) {
+			t.Errorf("expected %q in %q", expected, result.Details)
+		}
+	}
+}
+
+func TestBankCheckerNotInitialized(t *testing.T) {
+	result := checkBank([]gorgon.Operation{op(readAll, 1, 2, []int{10, 10, 10})})
+	if result.Result != gorgon.CheckUnknown {
+		t.Errorf("expected unknown, got %s", result.Result)
+	}
+}
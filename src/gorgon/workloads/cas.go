@@ -0,0 +1,52 @@
+package workloads
+
+import (
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// CasWorkload mixes Gets, Sets and compare-and-sets on eight keys, and checks
+// that the history is linearizable under CasModel.
+func CasWorkload() gorgon.Workload {
+	keys := generators.Keys(8)
+	return gorgon.Workload{
+		Model:      CasModel(),
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewCasGenerator(keys), time.Millisecond)},
+		Final:      []gorgon.Generator{generators.NewFinalReadGenerator(keys, finalGet)},
+	}
+}
+
+// CasModel extends GetSetModel with CasInstruction. A Cas on a missing key
+// never succeeds.
+func CasModel() gorgon.Model {
+	model := GetSetModel()
+	getSetStep := model.Step
+	model.Step = func(state gorgon.State, input gorgon.Instruction, output interface{}) []gorgon.State {
+		instr, ok := input.(*generators.CasInstruction)
+		if !ok {
+			return getSetStep(state, input, output)
+		}
+		stateMap := state.(IntMap)
+		val, ok := stateMap.Get(instr.Key)
+		matches := ok && val == instr.Expected
+		switch out := output.(type) {
+		case bool:
+			if out != matches {
+				return nil
+			}
+			if matches {
+				return []gorgon.State{stateMap.Put(instr.Key, instr.Value)}
+			}
+			return []gorgon.State{state}
+		case error:
+			if matches && !gorgon.IsUnambiguousError(out) {
+				return []gorgon.State{state, stateMap.Put(instr.Key, instr.Value)}
+			}
+			return []gorgon.State{state}
+		}
+		return nil
+	}
+	return model
+}
@@ -0,0 +1,144 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// CounterWorkload adds positive and negative deltas to four counters and
+// reads them. As increments commute, CounterChecker only bounds every read by
+// the deltas that may have been applied before it.
+func CounterWorkload() gorgon.Workload {
+	keys := []string{"counter0", "counter1", "counter2", "counter3"}
+	return gorgon.Workload{
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewCounterGenerator(keys), time.Millisecond)},
+		Checkers:   []gorgon.Checker{CounterChecker()},
+		Final: []gorgon.Generator{generators.NewFinalReadGenerator(keys, func(key string) gorgon.Instruction {
+			return &generators.ReadCounterInstruction{Key: key}
+		})},
+	}
+}
+
+// CounterChecker returns a Checker of histories of IncrInstruction and
+// ReadCounterInstruction. For every read of a counter, the lower bound is
+// the sum of the increments acknowledged before the read was called and of
+// the decrements that may have taken effect before it returned. The upper
+// bound is the other way around. A read outside its bounds is illegal.
+func CounterChecker() gorgon.Checker {
+	return counterChecker{}
+}
+
+type counterChecker struct{}
+
+func (counterChecker) Name() string {
+	return "Counter"
+}
+
+func (counterChecker) Check(ctx context.Context, history []gorgon.Operation,
+	opt *gorgon.CheckOptions) ([]gorgon.PartitionResult, error) {
+	var results []gorgon.PartitionResult
+	for i, part := range PartitionByKey(history) {
+		result := checkCounter(part)
+		result.Index = i
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// deltaSums answers the sum of the positive and negative deltas of the
+// operations whose time is before a given time.
+type deltaSums struct {
+	times    []int64
+	pos, neg []int // prefix sums
+}
+
+func (sums *deltaSums) add(time int64, delta int) {
+	pos, neg := 0, 0
+	if delta > 0 {
+		pos = delta
+	} else {
+		neg = delta
+	}
+	sums.times = append(sums.times, time)
+	sums.pos = append(sums.pos, pos)
+	sums.neg = append(sums.neg, neg)
+}
+
+func (sums *deltaSums) prepare() {
+	index := make([]int, len(sums.times))
+	for i := range index {
+		index[i] = i
+	}
+	sort.Slice(index, func(i, j int) bool { return sums.times[index[i]] < sums.times[index[j]] })
+	times := make([]int64, len(index))
+	pos := make([]int, len(index)+1)
+	neg := make([]int, len(index)+1)
+	for i, j := range index {
+		times[i] = sums.times[j]
+		pos[i+1] = pos[i] + sums.pos[j]
+		neg[i+1] = neg[i] + sums.neg[j]
+	}
+	sums.times, sums.pos, sums.neg = times, pos, neg
+}
+
+func (sums *deltaSums) before(time int64) (pos, neg int) {
+	n := sort.Search(len(sums.times), func(i int) bool { return sums.times[i] >= time })
+	return sums.pos[n], sums.neg[n]
+}
+
+func checkCounter(history []gorgon.Operation) gorgon.PartitionResult {
+	var definite deltaSums // acknowledged, by return
+	var possible deltaSums // not failed unambiguously, by call
+	var reads []gorgon.Operation
+	for _, op := range history {
+		switch instr := op.Input.(type) {
+		case *generators.IncrInstruction:
+			if err, ok := op.Output.(error); ok {
+				if !gorgon.IsUnambiguousError(err) {
+					possible.add(op.Call, instr.Delta)
+				}
+			} else {
+				definite.add(op.Return, instr.Delta)
+				possible.add(op.Call, instr.Delta)
+			}
+		case *generators.ReadCounterInstruction:
+			if _, ok := op.Output.(int); ok {
+				reads = append(reads, op)
+			}
+		}
+	}
+	definite.prepare()
+	possible.prepare()
+
+	result := gorgon.PartitionResult{Operations: len(history), Result: gorgon.CheckOk}
+	const maxDetails = 5
+	var details []string
+	bad := 0
+	for _, read := range reads {
+		definitePos, definiteNeg := definite.before(read.Call)
+		possiblePos, possibleNeg := possible.before(read.Return)
+		lower := definitePos + possibleNeg
+		upper := possiblePos + definiteNeg
+		value := read.Output.(int)
+		if value >= lower && value <= upper {
+			continue
+		}
+		bad++
+		if len(details) < maxDetails {
+			details = append(details, fmt.Sprintf("client %d read %d not in [%d, %d]",
+				read.ClientId, value, lower, upper))
+		}
+	}
+	if bad != 0 {
+		key := history[0].Input.(interface{ GetKey() string }).GetKey()
+		result.Result = gorgon.CheckIllegal
+		result.Details = fmt.Sprintf("%q: %d reads out of bounds; %s", key, bad, strings.Join(details, "; "))
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package workloads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+func incr(delta int) gorgon.Instruction {
+	return &generators.IncrInstruction{Key: "k", Delta: delta}
+}
+
+var readCounter = &generators.ReadCounterInstruction{Key: "k"}
+
+func TestCounterCheckerOk(t *testing.T) {
+	result := checkCounter([]gorgon.Operation{
+		op(readCounter, 0, 1, 0),
+		op(incr(5), 1, 2, nil),
+		op(incr(3), 3, 100, errors.New("timeout")),
+		op(incr(7), 3, 4, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(incr(-2), 5, 8, nil),
+		op(readCounter, 6, 7, 8), // 5 + 3, before the decrement
+		op(readCounter, 9, 10, 3),
+		op(readCounter, 9, 10, 6),
+	})
+	if result.Result != gorgon.CheckOk {
+		t.Errorf("expected ok, got %s: %s", result.Result, result.Details)
+	}
+}
+
+func TestCounterCheckerOutOfBounds(t *testing.T) {
+	result := checkCounter([]gorgon.Operation{
+		op(incr(5), 1, 2, nil),
+		op(incr(7), 3, 4, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(readCounter, 5, 6, 12),
+		op(readCounter, 5, 6, 4),
+		op(readCounter, 5, 6, 5),
+	})
+	if result.Result != gorgon.CheckIllegal {
+		t.Fatalf("expected illegal, got %s", result.Result)
+	}
+	for _, expected := range []string{"2 reads out of bounds", "read 12 not in [5, 5]", "read 4 not in [5, 5]"} {
+		if !strings.Contains(result.Details, expected) {
+			t.Errorf("expected %q in %q", expected, result.Details)
+		}
+	}
+}
@@ -15,8 +15,12 @@ func DescribeOperation(input gorgon.Instruction, output interface{}) string {
 		switch rv := output.(type) {
 		case int:
 			returnValue = strconv.Itoa(rv)
+		case bool:
+			returnValue = strconv.FormatBool(rv)
 		case string:
 			returnValue = rv
+		case []int:
+			returnValue = fmt.Sprint(rv)
 		case interface{ String() string }:
 			returnValue = rv.String()
 		case error:
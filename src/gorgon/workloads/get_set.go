@@ -7,14 +7,33 @@ import (
 	"github.com/pavlosg/gorgon/src/gorgon/generators"
 )
 
+// DefaultGetSetConfig returns the configuration of GetSetWorkload: eight
+// uniformly chosen keys, the Gets and Sets of NewGetSetGenerator and plain
+// int values.
+func DefaultGetSetConfig() generators.GetSetConfig {
+	return generators.GetSetConfig{
+		Keys:         generators.Keys(8),
+		Distribution: generators.Uniform(),
+		ReadRatio:    generators.LegacyReadRatio,
+	}
+}
+
 func GetSetWorkload() gorgon.Workload {
-	keys := []string{"key0", "key1", "key2", "key3", "key4", "key5", "key6", "key7"}
+	return GetSetWorkloadWith(DefaultGetSetConfig())
+}
+
+func GetSetWorkloadWith(config generators.GetSetConfig) gorgon.Workload {
 	return gorgon.Workload{
 		Model:      GetSetModel(),
-		Generators: []gorgon.Generator{generators.Stagger(generators.NewGetSetGenerator(keys), time.Millisecond)},
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewGetSetGeneratorWith(config), time.Millisecond)},
+		Final:      []gorgon.Generator{generators.NewFinalReadGenerator(config.Keys, finalGet)},
 	}
 }
 
+func finalGet(key string) gorgon.Instruction {
+	return &generators.GetInstruction{Key: key}
+}
+
 func GetSetModel() gorgon.Model {
 	return gorgon.Model{
 		Init: func() []gorgon.State { return []gorgon.State{IntMap{}} },
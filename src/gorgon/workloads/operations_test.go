@@ -0,0 +1,8 @@
+package workloads
+
+import "github.com/pavlosg/gorgon/src/gorgon"
+
+// op returns an operation of client 0 for the checker tests.
+func op(input gorgon.Instruction, call, ret int64, output gorgon.Output) gorgon.Operation {
+	return gorgon.Operation{Input: input, Call: call, Return: ret, Output: output}
+}
@@ -0,0 +1,161 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// Delivery is the guarantee of a queue for the elements that were enqueued.
+type Delivery int
+
+const (
+	ExactlyOnce Delivery = iota
+	AtLeastOnce          // an element may be dequeued more than once
+)
+
+// QueueWorkload enqueues unique elements to four queues and dequeues them,
+// then drains the queues once the workload duration is over, so that
+// QueueChecker can account for every element that was acknowledged.
+func QueueWorkload() gorgon.Workload {
+	queues := []string{"queue0", "queue1", "queue2", "queue3"}
+	return gorgon.Workload{
+		Generators: []gorgon.Generator{generators.Stagger(generators.NewQueueGenerator(queues), time.Millisecond)},
+		Checkers:   []gorgon.Checker{QueueChecker(ExactlyOnce)},
+		Final:      []gorgon.Generator{generators.NewQueueDrainGenerator(queues)},
+	}
+}
+
+// QueueChecker returns a Checker of histories of EnqueueInstruction and
+// DequeueInstruction. For every queue, it classifies the elements as
+// delivered once, duplicated, lost or unexpected. An acknowledged element is
+// lost if it was never dequeued, although the queue was found empty after
+// it was enqueued. Elements that could have been taken by ambiguous dequeues
+// make the result unknown rather than illegal.
+func QueueChecker(delivery Delivery) gorgon.Checker {
+	return queueChecker{delivery: delivery}
+}
+
+type queueChecker struct {
+	delivery Delivery
+}
+
+func (checker queueChecker) Name() string {
+	if checker.delivery == AtLeastOnce {
+		return "QueueAtLeastOnce"
+	}
+	return "Queue"
+}
+
+func (checker queueChecker) Check(ctx context.Context, history []gorgon.Operation,
+	opt *gorgon.CheckOptions) ([]gorgon.PartitionResult, error) {
+	var results []gorgon.PartitionResult
+	for i, part := range PartitionByKey(history) {
+		result := checkQueue(part, checker.delivery)
+		result.Index = i
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func checkQueue(history []gorgon.Operation, delivery Delivery) gorgon.PartitionResult {
+	attempted := make(map[int]bool)
+	failed := make(map[int]bool)
+	acked := make(map[int]int64)
+	dequeued := make(map[int]int)
+	ambiguousDequeues := 0
+	drained := int64(-1) // call of the last dequeue that found the queue empty
+	for _, op := range history {
+		switch instr := op.Input.(type) {
+		case *generators.EnqueueInstruction:
+			attempted[instr.Element] = true
+			if err, ok := op.Output.(error); ok {
+				if gorgon.IsUnambiguousError(err) {
+					failed[instr.Element] = true
+				}
+			} else {
+				acked[instr.Element] = op.Return
+			}
+		case *generators.DequeueInstruction:
+			switch out := op.Output.(type) {
+			case nil:
+				if op.Call > drained {
+					drained = op.Call
+				}
+			case int:
+				dequeued[out]++
+			case error:
+				if !gorgon.IsUnambiguousError(out) {
+					ambiguousDequeues++
+				}
+			}
+		}
+	}
+
+	once := 0
+	var duplicated, unexpected, lost, undrained elementSet
+	for e, n := range dequeued {
+		if !attempted[e] || failed[e] {
+			unexpected.add(e)
+		}
+		if n > 1 {
+			duplicated.add(e)
+		} else {
+			once++
+		}
+	}
+	for e, t := range acked {
+		if dequeued[e] != 0 {
+			continue
+		}
+		if t < drained {
+			lost.add(e)
+		} else {
+			undrained.add(e)
+		}
+	}
+
+	key := ""
+	if len(history) != 0 {
+		key = history[0].Input.(interface{ GetKey() string }).GetKey()
+	}
+	result := gorgon.PartitionResult{Operations: len(history), Result: gorgon.CheckOk}
+	var details []string
+	illegal := len(unexpected.list) != 0
+	if len(duplicated.list) != 0 {
+		details = append(details, fmt.Sprintf("duplicated %s", &duplicated))
+		illegal = illegal || delivery == ExactlyOnce
+	}
+	if len(unexpected.list) != 0 {
+		details = append(details, fmt.Sprintf("unexpected %s", &unexpected))
+	}
+	unknown := false
+	if n := len(lost.list); n != 0 {
+		if n > ambiguousDequeues {
+			illegal = true
+			details = append(details, fmt.Sprintf("lost %s", &lost))
+		} else {
+			unknown = true
+			details = append(details, fmt.Sprintf("lost %s, or taken by %d ambiguous dequeues",
+				&lost, ambiguousDequeues))
+		}
+	}
+	if len(undrained.list) != 0 {
+		unknown = true
+		details = append(details, fmt.Sprintf("not drained %s", &undrained))
+	}
+	switch {
+	case illegal:
+		result.Result = gorgon.CheckIllegal
+	case unknown:
+		result.Result = gorgon.CheckUnknown
+	}
+	if len(details) != 0 {
+		result.Details = fmt.Sprintf("%q: delivered once %d, %s", key, once, strings.Join(details, ", "))
+	}
+	return result
+}
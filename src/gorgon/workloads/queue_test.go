@@ -0,0 +1,60 @@
+package workloads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+func enqueue(element int) gorgon.Instruction {
+	return &generators.EnqueueInstruction{Queue: "q", Element: element}
+}
+
+var dequeue = &generators.DequeueInstruction{Queue: "q"}
+
+func TestQueueCheckerOk(t *testing.T) {
+	result := checkQueue([]gorgon.Operation{
+		op(enqueue(1), 1, 2, nil),
+		op(enqueue(2), 1, 20, errors.New("timeout")),
+		op(enqueue(3), 1, 2, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(dequeue, 3, 4, 1),
+		op(dequeue, 5, 6, nil),
+	}, ExactlyOnce)
+	if result.Result != gorgon.CheckOk {
+		t.Errorf("expected ok, got %s: %s", result.Result, result.Details)
+	}
+}
+
+func TestQueueCheckerAnomalies(t *testing.T) {
+	history := []gorgon.Operation{
+		op(enqueue(1), 1, 2, nil),
+		op(enqueue(2), 1, 2, nil),
+		op(enqueue(3), 1, 2, gorgon.WrapUnambiguousError(errors.New("busy"))),
+		op(enqueue(4), 1, 2, nil),
+		op(dequeue, 3, 4, 1),
+		op(dequeue, 3, 4, 1),
+		op(dequeue, 3, 4, 3),
+		op(dequeue, 5, 6, nil),
+		op(enqueue(5), 7, 8, nil),
+	}
+	result := checkQueue(history, ExactlyOnce)
+	if result.Result != gorgon.CheckIllegal {
+		t.Fatalf("expected illegal, got %s", result.Result)
+	}
+	for _, expected := range []string{"delivered once 1", "duplicated [1] (1)", "unexpected [3] (1)",
+		"lost [2 4] (2)", "not drained [5] (1)"} {
+		if !strings.Contains(result.Details, expected) {
+			t.Errorf("expected %q in %q", expected, result.Details)
+		}
+	}
+
+	history = append(history, op(dequeue, 3, 4, errors.New("timeout")), op(dequeue, 3, 4, errors.New("timeout")))
+	history[6] = op(dequeue, 3, 4, 5)
+	result = checkQueue(history, AtLeastOnce)
+	if result.Result != gorgon.CheckUnknown {
+		t.Errorf("expected unknown, got %s: %s", result.Result, result.Details)
+	}
+}
@@ -0,0 +1,100 @@
+package kv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
+)
+
+// initAccounts upserts every account. No transfers run concurrently, so it
+// does not need a transaction.
+func (client *client) initAccounts(instr *generators.InitAccountsInstruction) gorgon.Output {
+	for i, key := range instr.Keys {
+		_, err := client.collection.Upsert(key, instr.Balance,
+			&gocb.UpsertOptions{DurabilityLevel: client.durability, Timeout: client.config.Timeout})
+		if err != nil {
+			if i != 0 {
+				return fmt.Errorf("initialized only %q of the accounts: %w", instr.Keys[:i], err)
+			}
+			return mutationError(err)
+		}
+	}
+	return nil
+}
+
+func (client *client) transfer(instr *generators.TransferInstruction) gorgon.Output {
+	transferred := false
+	_, err := client.cluster.Transactions().Run(func(ctx *gocb.TransactionAttemptContext) error {
+		transferred = false
+		from, err := ctx.Get(client.collection, instr.From)
+		if err != nil {
+			return err
+		}
+		to, err := ctx.Get(client.collection, instr.To)
+		if err != nil {
+			return err
+		}
+		var fromBalance, toBalance int
+		if err := from.Content(&fromBalance); err != nil {
+			return err
+		}
+		if err := to.Content(&toBalance); err != nil {
+			return err
+		}
+		if fromBalance < instr.Amount {
+			return nil
+		}
+		if _, err := ctx.Replace(from, fromBalance-instr.Amount); err != nil {
+			return err
+		}
+		if _, err := ctx.Replace(to, toBalance+instr.Amount); err != nil {
+			return err
+		}
+		transferred = true
+		return nil
+	}, client.transactionOptions())
+	if err != nil {
+		return transactionError(err)
+	}
+	return transferred
+}
+
+func (client *client) readAll(instr *generators.ReadAllInstruction) gorgon.Output {
+	var balances []int
+	_, err := client.cluster.Transactions().Run(func(ctx *gocb.TransactionAttemptContext) error {
+		balances = make([]int, len(instr.Keys))
+		for i, key := range instr.Keys {
+			doc, err := ctx.Get(client.collection, key)
+			if err != nil {
+				return err
+			}
+			if err := doc.Content(&balances[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, client.transactionOptions())
+	if err != nil {
+		// A read-only transaction has no effect
+		return gorgon.WrapUnambiguousError(err)
+	}
+	return balances
+}
+
+func (client *client) transactionOptions() *gocb.TransactionOptions {
+	return &gocb.TransactionOptions{DurabilityLevel: client.durability}
+}
+
+// transactionError returns err as the output of a transaction, wrapped if the
+// transaction certainly did not commit.
+func transactionError(err error) gorgon.Output {
+	var failed *gocb.TransactionFailedError
+	var expired *gocb.TransactionExpiredError
+	if errors.As(err, &failed) || errors.As(err, &expired) {
+		return gorgon.WrapUnambiguousError(err)
+	}
+	return err
+}
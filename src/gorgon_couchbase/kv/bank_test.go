@@ -0,0 +1,31 @@
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/pavlosg/gorgon/src/gorgon"
+)
+
+func TestTransactionError(t *testing.T) {
+	for _, test := range []struct {
+		err         error
+		unambiguous bool
+	}{
+		{fmt.Errorf("transfer: %w", &gocb.TransactionFailedError{}), true},
+		{fmt.Errorf("transfer: %w", &gocb.TransactionExpiredError{}), true},
+		{&gocb.TransactionCommitAmbiguousError{}, false},
+		{errors.New("timeout"), false},
+	} {
+		output := transactionError(test.err)
+		err, ok := output.(error)
+		if !ok {
+			t.Fatalf("%v: output %v is not an error", test.err, output)
+		}
+		if gorgon.IsUnambiguousError(err) != test.unambiguous {
+			t.Errorf("%v: unambiguous %v, want %v", test.err, !test.unambiguous, test.unambiguous)
+		}
+	}
+}
@@ -92,8 +92,12 @@ func (client *client) Invoke(instruction gorgon.Instruction, getTime func() int6
 			}
 			return
 		}
+		var doc json.RawMessage
 		val := 0
-		err = result.Content(&val)
+		err = result.Content(&doc)
+		if err == nil {
+			val, err = generators.DecodeValue(doc)
+		}
 		if err != nil {
 			output = gorgon.WrapUnambiguousError(err)
 		} else {
@@ -101,22 +105,204 @@ func (client *client) Invoke(instruction gorgon.Instruction, getTime func() int6
 		}
 		return
 	case *generators.SetInstruction:
-		_, err := client.collection.Upsert(instr.Key, instr.Value,
+		var value interface{} = instr.Value
+		if instr.Size != 0 {
+			value = json.RawMessage(generators.EncodeValue(instr.Value, instr.Size))
+		}
+		_, err := client.collection.Upsert(instr.Key, value,
 			&gocb.UpsertOptions{DurabilityLevel: client.durability, Timeout: client.config.Timeout})
 		retTime = getTime()
 		if err != nil {
-			if errors.Is(err, gocb.ErrUnambiguousTimeout) ||
-				errors.Is(err, gocb.ErrDurabilityImpossible) {
+			output = mutationError(err)
+		}
+		return
+	case *generators.CasInstruction:
+		result, err := client.collection.Get(instr.Key, &gocb.GetOptions{Timeout: client.config.Timeout})
+		if err != nil {
+			retTime = getTime()
+			if errors.Is(err, gocb.ErrDocumentNotFound) {
+				output = false
+			} else {
+				output = gorgon.WrapUnambiguousError(err)
+			}
+			return
+		}
+		val := 0
+		if err = result.Content(&val); err != nil {
+			return getTime(), gorgon.WrapUnambiguousError(err)
+		}
+		if val != instr.Expected {
+			return getTime(), false
+		}
+		_, err = client.collection.Replace(instr.Key, instr.Value, &gocb.ReplaceOptions{
+			Cas:             result.Cas(),
+			DurabilityLevel: client.durability,
+			Timeout:         client.config.Timeout})
+		retTime = getTime()
+		if err != nil {
+			if errors.Is(err, gocb.ErrCasMismatch) || errors.Is(err, gocb.ErrDocumentNotFound) {
+				output = false
+			} else {
+				output = mutationError(err)
+			}
+		} else {
+			output = true
+		}
+		return
+	case *generators.AppendInstruction:
+		_, err := client.collection.MutateIn(instr.Key,
+			[]gocb.MutateInSpec{gocb.ArrayAppendSpec("", instr.Element, nil)},
+			&gocb.MutateInOptions{
+				StoreSemantic:   gocb.StoreSemanticsUpsert,
+				DurabilityLevel: client.durability,
+				Timeout:         client.config.Timeout})
+		retTime = getTime()
+		if err != nil {
+			output = mutationError(err)
+		}
+		return
+	case *generators.ReadSetInstruction:
+		result, err := client.collection.Get(instr.Key, &gocb.GetOptions{Timeout: client.config.Timeout})
+		retTime = getTime()
+		if err != nil {
+			if errors.Is(err, gocb.ErrDocumentNotFound) {
+				output = []int{}
+			} else {
+				output = gorgon.WrapUnambiguousError(err)
+			}
+			return
+		}
+		var elements []int
+		if err = result.Content(&elements); err != nil {
+			output = gorgon.WrapUnambiguousError(err)
+		} else {
+			output = elements
+		}
+		return
+	case *generators.IncrInstruction:
+		var err error
+		binary := client.collection.Binary()
+		if instr.Delta >= 0 {
+			_, err = binary.Increment(instr.Key, &gocb.IncrementOptions{
+				Initial:         int64(instr.Delta),
+				Delta:           uint64(instr.Delta),
+				DurabilityLevel: client.durability,
+				Timeout:         client.config.Timeout})
+		} else {
+			// The generator never decrements a counter that may be missing
+			_, err = binary.Decrement(instr.Key, &gocb.DecrementOptions{
+				Initial:         -1,
+				Delta:           uint64(-instr.Delta),
+				DurabilityLevel: client.durability,
+				Timeout:         client.config.Timeout})
+		}
+		retTime = getTime()
+		if err != nil {
+			if errors.Is(err, gocb.ErrDocumentNotFound) {
 				output = gorgon.WrapUnambiguousError(err)
 			} else {
-				output = err
+				output = mutationError(err)
+			}
+		}
+		return
+	case *generators.ReadCounterInstruction:
+		result, err := client.collection.Get(instr.Key, &gocb.GetOptions{Timeout: client.config.Timeout})
+		retTime = getTime()
+		if err != nil {
+			if errors.Is(err, gocb.ErrDocumentNotFound) {
+				output = 0
+			} else {
+				output = gorgon.WrapUnambiguousError(err)
 			}
+			return
+		}
+		val := 0
+		if err = result.Content(&val); err != nil {
+			output = gorgon.WrapUnambiguousError(err)
+		} else {
+			output = val
+		}
+		return
+	case *generators.EnqueueInstruction:
+		_, err := client.collection.MutateIn(instr.Queue,
+			[]gocb.MutateInSpec{gocb.ArrayPrependSpec("", instr.Element, nil)},
+			&gocb.MutateInOptions{
+				StoreSemantic:   gocb.StoreSemanticsUpsert,
+				DurabilityLevel: client.durability,
+				Timeout:         client.config.Timeout})
+		retTime = getTime()
+		if err != nil {
+			output = mutationError(err)
 		}
 		return
+	case *generators.DequeueInstruction:
+		output = client.dequeue(instr)
+		return getTime(), output
+	case *generators.InitAccountsInstruction:
+		output = client.initAccounts(instr)
+		return getTime(), output
+	case *generators.TransferInstruction:
+		output = client.transfer(instr)
+		return getTime(), output
+	case *generators.ReadAllInstruction:
+		output = client.readAll(instr)
+		return getTime(), output
 	}
 	return getTime(), gorgon.ErrUnsupportedInstruction
 }
 
+// dequeue removes the last element of the array at instr.Queue, retrying
+// if another client changed the array in the meantime.
+func (client *client) dequeue(instr *generators.DequeueInstruction) gorgon.Output {
+	const maxAttempts = 16
+	for i := 0; i < maxAttempts; i++ {
+		result, err := client.collection.LookupIn(instr.Queue,
+			[]gocb.LookupInSpec{gocb.GetSpec("[-1]", nil)},
+			&gocb.LookupInOptions{Timeout: client.config.Timeout})
+		if err != nil {
+			if errors.Is(err, gocb.ErrDocumentNotFound) {
+				return nil
+			}
+			return gorgon.WrapUnambiguousError(err)
+		}
+		if !result.Exists(0) {
+			return nil
+		}
+		element := 0
+		if err = result.ContentAt(0, &element); err != nil {
+			return gorgon.WrapUnambiguousError(err)
+		}
+		_, err = client.collection.MutateIn(instr.Queue,
+			[]gocb.MutateInSpec{gocb.RemoveSpec("[-1]", nil)},
+			&gocb.MutateInOptions{
+				Cas:             result.Cas(),
+				DurabilityLevel: client.durability,
+				Timeout:         client.config.Timeout})
+		if errors.Is(err, gocb.ErrCasMismatch) {
+			continue
+		}
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			// The queue was removed after the lookup, so it is empty
+			return nil
+		}
+		if err != nil {
+			return mutationError(err)
+		}
+		return element
+	}
+	return gorgon.WrapUnambiguousError(errors.New("kv: dequeue kept conflicting"))
+}
+
+// mutationError returns err as the output of a mutation, wrapped if the
+// mutation certainly did not take effect.
+func mutationError(err error) gorgon.Output {
+	if errors.Is(err, gocb.ErrUnambiguousTimeout) ||
+		errors.Is(err, gocb.ErrDurabilityImpossible) {
+		return gorgon.WrapUnambiguousError(err)
+	}
+	return err
+}
+
 func parseDurabilityLevel(level string) gocb.DurabilityLevel {
 	switch level {
 	case "none":
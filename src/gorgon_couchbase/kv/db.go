@@ -12,6 +12,7 @@ import (
 
 	"github.com/couchbase/gocb/v2"
 	"github.com/pavlosg/gorgon/src/gorgon"
+	"github.com/pavlosg/gorgon/src/gorgon/generators"
 	"github.com/pavlosg/gorgon/src/gorgon/log"
 	"github.com/pavlosg/gorgon/src/gorgon/nemeses"
 	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
@@ -26,6 +27,10 @@ type DatabaseConfig struct {
 	Durability    *string
 	Timeout       *time.Duration
 	ClientOverRpc *bool
+	Keys          *int
+	Distribution  *string
+	ReadRatio     *float64
+	ValueSize     *int
 }
 
 func NewDatabase(config DatabaseConfig) gorgon.Database {
@@ -36,6 +41,7 @@ type database struct {
 	config     DatabaseConfig
 	options    *gorgon.Options
 	durability gocb.DurabilityLevel
+	getSet     generators.GetSetConfig
 }
 
 func (*database) Name() string {
@@ -53,6 +59,25 @@ func (db *database) SetOptions(opt *gorgon.Options) error {
 	if n := *db.config.Replicas; n < 0 || n > 3 {
 		return fmt.Errorf("kv: invalid number of replicas %d", n)
 	}
+	distribution, err := generators.ParseKeyDistribution(*db.config.Distribution)
+	if err != nil {
+		return fmt.Errorf("kv: %v", err)
+	}
+	if n := *db.config.Keys; n < 1 {
+		return fmt.Errorf("kv: invalid number of keys %d", n)
+	}
+	if r := *db.config.ReadRatio; r != generators.LegacyReadRatio && (r < 0 || r > 1) {
+		return fmt.Errorf("kv: invalid read ratio %v", r)
+	}
+	if n := *db.config.ValueSize; n < 0 || n > 20<<20 {
+		return fmt.Errorf("kv: invalid value size %d", n)
+	}
+	db.getSet = generators.GetSetConfig{
+		Keys:         generators.Keys(*db.config.Keys),
+		Distribution: distribution,
+		ReadRatio:    *db.config.ReadRatio,
+		ValueSize:    *db.config.ValueSize,
+	}
 	return nil
 }
 
@@ -220,8 +245,18 @@ func (db *database) ClientConfig() string {
 
 func (db *database) Workloads() []gorgon.Workload {
 	return []gorgon.Workload{
-		workloads.GetSetWorkload(),
-		workloads.GetSetWorkload().Add(nemeses.NewKillNemesis("memcached")).Add(NewSetAfterKillGenerator()),
-		workloads.GetSetWorkload().Add(nemeses.NewNetworkPartitionNemesis(8091)).Add(NewPartitionAwareGetSetGenerator()),
+		workloads.GetSetWorkloadWith(db.getSet),
+		workloads.GetSetWorkloadWith(db.getSet).Add(nemeses.NewKillNemesis("memcached")).Add(NewSetAfterKillGenerator(db.getSet.Keys)),
+		workloads.GetSetWorkloadWith(db.getSet).Add(nemeses.NewNetworkPartitionNemesis(8091)).Add(NewPartitionAwareGetSetGenerator(db.getSet.Keys)),
+		workloads.CasWorkload(),
+		workloads.CasWorkload().Add(nemeses.NewNetworkPartitionNemesis(8091)),
+		workloads.AppendWorkload(),
+		workloads.AppendWorkload().Add(nemeses.NewKillNemesis("memcached")),
+		workloads.BankWorkload(),
+		workloads.BankWorkload().Add(nemeses.NewKillNemesis("memcached")),
+		workloads.CounterWorkload(),
+		workloads.CounterWorkload().Add(nemeses.NewKillNemesis("memcached")),
+		workloads.QueueWorkload(),
+		workloads.QueueWorkload().Add(nemeses.NewKillNemesis("memcached")),
 	}
 }
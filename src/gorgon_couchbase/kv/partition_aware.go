@@ -10,13 +10,12 @@ import (
 	"github.com/pavlosg/gorgon/src/gorgon/splitmix"
 )
 
-func NewPartitionAwareGetSetGenerator() gorgon.Generator {
-	return generators.Stagger(&partitionAwareGenerator{
-		keys: []string{"key0", "key1", "key2", "key3", "key4", "key5", "key6", "key7"},
-		rand: splitmix.NewRand()}, 10*time.Millisecond)
+func NewPartitionAwareGetSetGenerator(keys []string) gorgon.Generator {
+	return generators.Stagger(&partitionAwareGenerator{keys: keys}, 10*time.Millisecond)
 }
 
 type partitionAwareGenerator struct {
+	generators.Base
 	keys     []string
 	rand     *rand.Rand
 	numNodes int
@@ -46,23 +45,12 @@ func (*partitionAwareGenerator) Name() string {
 }
 
 func (gen *partitionAwareGenerator) SetUp(opt *gorgon.Options) error {
+	gen.rand = splitmix.NewRandSeed(opt.Seed)
 	gen.numNodes = len(opt.Nodes)
 	gen.node = -1
 	return nil
 }
 
-func (*partitionAwareGenerator) TearDown() error {
-	return nil
-}
-
-func (*partitionAwareGenerator) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
-	return -1, gorgon.ErrUnsupportedInstruction
-}
-
-func (*partitionAwareGenerator) OnCall(client int, instruction gorgon.Instruction) error {
-	return nil
-}
-
 func (gen *partitionAwareGenerator) OnReturn(client int, instruction gorgon.Instruction, output gorgon.Output) error {
 	if instr, ok := instruction.(*nemeses.PartitionNodeInstruction); ok {
 		if instr.Heal {
@@ -1,17 +1,19 @@
 package kv
 
 import (
+	"fmt"
+
 	"github.com/pavlosg/gorgon/src/gorgon"
 	"github.com/pavlosg/gorgon/src/gorgon/generators"
 	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
 )
 
-func NewSetAfterKillGenerator() gorgon.Generator {
-	return &setAfterKillGenerator{
-		keys: []string{"key0", "key1", "key2", "key3", "key4", "key5", "key6", "key7"}}
+func NewSetAfterKillGenerator(keys []string) gorgon.Generator {
+	return &setAfterKillGenerator{keys: keys}
 }
 
 type setAfterKillGenerator struct {
+	generators.Base
 	keys   []string
 	client int
 	key    int
@@ -37,18 +39,14 @@ func (*setAfterKillGenerator) Name() string {
 }
 
 func (gen *setAfterKillGenerator) SetUp(opt *gorgon.Options) error {
+	if opt.Rate > 0 {
+		// A client has to see its Sets return before the next one
+		return fmt.Errorf("SetAfterKill generator does not support open-loop mode")
+	}
 	gen.client = -1
 	return nil
 }
 
-func (*setAfterKillGenerator) TearDown() error {
-	return nil
-}
-
-func (*setAfterKillGenerator) Invoke(instruction gorgon.Instruction, getTime func() int64) (int64, gorgon.Output) {
-	return -1, gorgon.ErrUnsupportedInstruction
-}
-
 func (gen *setAfterKillGenerator) OnCall(client int, instruction gorgon.Instruction) error {
 	if _, ok := instruction.(*rpcs.KillInstruction); ok && gen.client < 0 {
 		gen.client = 1
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pavlosg/gorgon/src/gorgon/cmd"
 	"github.com/pavlosg/gorgon/src/gorgon/generators"
+	"github.com/pavlosg/gorgon/src/gorgon/nemeses"
 	"github.com/pavlosg/gorgon/src/gorgon/rpcs"
 	"github.com/pavlosg/gorgon/src/gorgon_couchbase/kv"
 )
@@ -24,14 +25,34 @@ func main() {
 		Durability:    flag.String("durability", "none", "Couchbase durability level"),
 		Timeout:       flag.Duration("timeout", 5*time.Second, "Couchbase operation timeout"),
 		ClientOverRpc: flag.Bool("client-over-rpc", false, "Use RPC for client operations"),
+		Keys:          flag.Int("keys", 8, "Number of keys of the GetSet workloads"),
+		Distribution:  flag.String("key-distribution", "uniform", "Key distribution of the GetSet workloads (uniform, zipfian, hotspot)"),
+		ReadRatio:     flag.Float64("read-ratio", generators.LegacyReadRatio, "Fraction of Gets in the GetSet workloads, -1 for Gets only on odd clients and half the time on even ones"),
+		ValueSize:     flag.Int("value-size", 0, "Size in bytes of the documents written by the GetSet workloads, 0 for plain ints"),
 	})
 
 	rpc.Register(rpcs.NewClientRpc(db))
 	rpc.Register(&rpcs.IpTablesRpc{})
 	rpc.Register(&rpcs.KillRpc{})
+	rpc.Register(rpcs.NewDiagnosticsRpc(
+		[]string{"/opt/couchbase/var/lib/couchbase/logs"},
+		[][]string{{"/opt/couchbase/bin/cbcollect_info", "cbcollect_info.zip"}},
+	))
 
 	rpcs.RegisterInstruction(&generators.GetInstruction{})
 	rpcs.RegisterInstruction(&generators.SetInstruction{})
+	rpcs.RegisterInstruction(&generators.CasInstruction{})
+	rpcs.RegisterInstruction(&generators.AppendInstruction{})
+	rpcs.RegisterInstruction(&generators.ReadSetInstruction{})
+	rpcs.RegisterInstruction(&generators.IncrInstruction{})
+	rpcs.RegisterInstruction(&generators.ReadCounterInstruction{})
+	rpcs.RegisterInstruction(&generators.EnqueueInstruction{})
+	rpcs.RegisterInstruction(&generators.DequeueInstruction{})
+	rpcs.RegisterInstruction(&generators.InitAccountsInstruction{})
+	rpcs.RegisterInstruction(&generators.TransferInstruction{})
+	rpcs.RegisterInstruction(&generators.ReadAllInstruction{})
+	rpcs.RegisterInstruction(&rpcs.KillInstruction{})
+	rpcs.RegisterInstruction(&nemeses.PartitionNodeInstruction{})
 
 	code := cmd.Main(db)
 	if code != 0 {